@@ -0,0 +1,289 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mangohow/gowlb/proc"
+	"github.com/sirupsen/logrus"
+)
+
+const defaultStopTimeout = 30 * time.Second
+
+// Component 由App负责启动和停止的组件, transport/http.Server 实现了该接口
+// Start 阻塞直到组件停止, Stop 需要停止接收新的请求并在ctx结束前处理完正在进行的请求
+type Component interface {
+	Start() error
+	Stop(ctx context.Context) error
+}
+
+// Hook 关闭钩子, 在所有组件停止后按注册的逆序执行, 用于关闭数据库连接等资源
+type Hook func(ctx context.Context) error
+
+type component struct {
+	name  string
+	start func() error
+	stop  func(ctx context.Context) error
+}
+
+type hook struct {
+	name string
+	fn   Hook
+}
+
+// App 启动所有组件并等待退出信号, 收到信号后优雅关闭:
+// 1. 并发停止所有组件, 等待正在处理的请求完成, 最长等待StopTimeout
+// 2. 按注册的逆序执行关闭钩子, 钩子使用单独的超时, 同样为StopTimeout, 不受组件停止超时的影响
+type App struct {
+	ctx         context.Context
+	cancel      context.CancelFunc
+	stopTimeout time.Duration
+	log         *logrus.Logger
+	// 没有通过WithContext设置ctx时, 在Run中监听退出信号
+	signal bool
+
+	components []component
+	hooks      []hook
+}
+
+type Option func(*App)
+
+// WithContext 设置App的生命周期, ctx结束时开始关闭
+// 默认在Run时使用 proc.SetupSignalHandler 返回的ctx, 即收到退出信号时关闭,
+// 信号处理在进程内只安装一次, 由所有App共享, 因此不要再单独调用 proc.SetupSignalHandler
+func WithContext(ctx context.Context) Option {
+	return func(a *App) {
+		a.ctx = ctx
+	}
+}
+
+// WithStopTimeout 设置停止组件和执行关闭钩子各自的最长等待时间, 默认为30s
+func WithStopTimeout(timeout time.Duration) Option {
+	return func(a *App) {
+		a.stopTimeout = timeout
+	}
+}
+
+func WithLogger(logger *logrus.Logger) Option {
+	return func(a *App) {
+		a.log = logger
+	}
+}
+
+// WithComponent 添加组件, name用于在日志和错误中标识组件
+func WithComponent(name string, c Component) Option {
+	return func(a *App) {
+		a.components = append(a.components, component{
+			name:  name,
+			start: c.Start,
+			stop:  c.Stop,
+		})
+	}
+}
+
+// WithStopper 添加只需要停止的组件, 例如已经启动的 workerpool 或 timer
+func WithStopper(name string, s proc.Stopper) Option {
+	return func(a *App) {
+		a.components = append(a.components, component{
+			name: name,
+			stop: func(ctx context.Context) error {
+				done := make(chan struct{})
+				go func() {
+					s.Stop()
+					close(done)
+				}()
+
+				select {
+				case <-done:
+					return nil
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			},
+		})
+	}
+}
+
+// WithShutdownHook 添加关闭钩子
+func WithShutdownHook(name string, fn Hook) Option {
+	return func(a *App) {
+		a.hooks = append(a.hooks, hook{
+			name: name,
+			fn:   fn,
+		})
+	}
+}
+
+func New(opts ...Option) *App {
+	a := &App{
+		stopTimeout: defaultStopTimeout,
+		log:         logrus.StandardLogger(),
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	if a.ctx == nil {
+		a.signal = true
+		a.ctx = context.Background()
+	}
+	a.ctx, a.cancel = context.WithCancel(a.ctx)
+
+	return a
+}
+
+var (
+	signalOnce sync.Once
+	signalCtx  context.Context
+)
+
+// signalContext 安装信号处理, proc.SetupSignalHandler 调用两次会panic, 因此只调用一次
+func signalContext() context.Context {
+	signalOnce.Do(func() {
+		signalCtx = proc.SetupSignalHandler()
+	})
+
+	return signalCtx
+}
+
+// Run 启动所有组件并阻塞, 直到收到退出信号、调用Stop或者有组件启动失败
+// 返回的错误为 Errors, 包含所有启动或停止失败的组件
+func (a *App) Run() error {
+	var (
+		mu   sync.Mutex
+		errs Errors
+	)
+	addErr := func(err error) {
+		mu.Lock()
+		errs = append(errs, err)
+		mu.Unlock()
+	}
+
+	for _, c := range a.components {
+		if c.start == nil {
+			continue
+		}
+		go func(c component) {
+			if err := c.start(); err != nil {
+				a.log.Errorf("app: start %s failed, err: %v", c.name, err)
+				addErr(&ComponentError{Name: c.name, Op: "start", Err: err})
+				a.cancel()
+			}
+		}(c)
+	}
+
+	var sigDone <-chan struct{}
+	if a.signal {
+		sigDone = signalContext().Done()
+	}
+	select {
+	case <-a.ctx.Done():
+	case <-sigDone:
+	}
+	a.log.Info("app: shutting down")
+
+	ctx, cancel := context.WithTimeout(context.Background(), a.stopTimeout)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for _, c := range a.components {
+		wg.Add(1)
+		go func(c component) {
+			defer wg.Done()
+			if err := c.stop(ctx); err != nil {
+				a.log.Errorf("app: stop %s failed, err: %v", c.name, err)
+				addErr(&ComponentError{Name: c.name, Op: "stop", Err: err})
+			}
+		}(c)
+	}
+	wg.Wait()
+
+	// 组件停止超时后ctx已经结束, 钩子使用新的ctx, 保证资源能够被正常关闭
+	hookCtx, hookCancel := context.WithTimeout(context.Background(), a.stopTimeout)
+	defer hookCancel()
+	for i := len(a.hooks) - 1; i >= 0; i-- {
+		h := a.hooks[i]
+		if err := h.fn(hookCtx); err != nil {
+			a.log.Errorf("app: shutdown hook %s failed, err: %v", h.name, err)
+			addErr(&ComponentError{Name: h.name, Op: "hook", Err: err})
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(errs) > 0 {
+		return errs
+	}
+	a.log.Info("app: shutdown complete")
+
+	return nil
+}
+
+// Stop 主动触发关闭, Run 会在关闭完成后返回
+func (a *App) Stop() {
+	a.cancel()
+}
+
+// ComponentError 记录启动或停止失败的组件
+// 停止超时的组件 Err 为 context.DeadlineExceeded
+type ComponentError struct {
+	Name string
+	// start、stop 或 hook
+	Op  string
+	Err error
+}
+
+func (e *ComponentError) Error() string {
+	if e.Err == context.DeadlineExceeded {
+		return fmt.Sprintf("%s %s did not finish in time", e.Op, e.Name)
+	}
+
+	return fmt.Sprintf("%s %s failed: %v", e.Op, e.Name, e.Err)
+}
+
+func (e *ComponentError) Unwrap() error {
+	return e.Err
+}
+
+// Errors 启动或关闭过程中的所有错误
+type Errors []error
+
+func (e Errors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+
+	return strings.Join(msgs, "; ")
+}
+
+// Unwrap 返回所有错误, 使 errors.Is 和 errors.As 能够检查其中的每个错误
+func (e Errors) Unwrap() []error {
+	return e
+}
+
+// Is 与 Unwrap 相同, go1.20之前的 errors.Is 不支持 Unwrap() []error
+func (e Errors) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// As 与 Unwrap 相同, go1.20之前的 errors.As 不支持 Unwrap() []error
+func (e Errors) As(target any) bool {
+	for _, err := range e {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
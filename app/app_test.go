@@ -0,0 +1,83 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type fakeComponent struct {
+	stopDelay time.Duration
+	stopped   chan struct{}
+}
+
+func (c *fakeComponent) Start() error {
+	<-c.stopped
+	return nil
+}
+
+func (c *fakeComponent) Stop(ctx context.Context) error {
+	defer close(c.stopped)
+	select {
+	case <-time.After(c.stopDelay):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func TestAppRun(t *testing.T) {
+	var order []string
+	hook := func(name string) Hook {
+		return func(ctx context.Context) error {
+			// slow用完了组件的停止超时, 钩子仍然拿到未结束的ctx
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if _, ok := ctx.Deadline(); !ok {
+				return errors.New("hook ctx has no deadline")
+			}
+			order = append(order, name)
+			return nil
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	a := New(
+		WithContext(ctx),
+		WithStopTimeout(50*time.Millisecond),
+		WithComponent("fast", &fakeComponent{stopped: make(chan struct{})}),
+		WithComponent("slow", &fakeComponent{stopDelay: time.Second, stopped: make(chan struct{})}),
+		WithShutdownHook("db", hook("db")),
+		WithShutdownHook("cache", hook("cache")),
+	)
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	err := a.Run()
+	var errs Errors
+	if !errors.As(err, &errs) || len(errs) != 1 {
+		t.Fatalf("Run() error = %v, want one error", err)
+	}
+	var ce *ComponentError
+	if !errors.As(errs[0], &ce) || ce.Name != "slow" || ce.Err != context.DeadlineExceeded {
+		t.Errorf("Run() error = %v, want slow did not finish in time", errs[0])
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("errors.Is(%v, context.DeadlineExceeded) = false", err)
+	}
+	if len(order) != 2 || order[0] != "cache" || order[1] != "db" {
+		t.Errorf("hooks order = %v, want [cache db]", order)
+	}
+}
+
+// 没有设置ctx的App共享同一个信号处理, 创建和运行多个App不会panic
+func TestAppSignalHandler(t *testing.T) {
+	for i := 0; i < 2; i++ {
+		a := New(WithComponent("c", &fakeComponent{stopped: make(chan struct{})}))
+		time.AfterFunc(10*time.Millisecond, a.Stop)
+		if err := a.Run(); err != nil {
+			t.Fatalf("Run() error = %v", err)
+		}
+	}
+}
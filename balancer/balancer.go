@@ -0,0 +1,257 @@
+package balancer
+
+import (
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mangohow/gowlb/errors"
+)
+
+// ErrNoAvailable 没有可用的服务实例
+var ErrNoAvailable = errors.ServiceUnavailable(errors.UnknownCode, "NoAvailableEndpoint", "no available endpoint")
+
+// Balancer 负载均衡算法, 从可用的实例中选择一个, nodes不为空
+type Balancer interface {
+	Pick(nodes []*Node) *Node
+}
+
+// DoneFunc 请求结束后调用, err不为nil表示实例出现了故障, 例如网络错误或者5xx
+type DoneFunc func(err error)
+
+// Node 服务实例, 记录正在处理的请求数、延迟以及连续失败的次数
+type Node struct {
+	addr string
+
+	inflight int64
+	// 指数加权移动平均延迟, 单位ns
+	latency  int64
+	failures int32
+	// 被剔除的截止时间, unix ns
+	ejectedUntil int64
+}
+
+// Address 实例地址
+func (n *Node) Address() string {
+	return n.addr
+}
+
+// Inflight 正在处理的请求数
+func (n *Node) Inflight() int64 {
+	return atomic.LoadInt64(&n.inflight)
+}
+
+// Latency 平均延迟, 没有请求时为0
+func (n *Node) Latency() time.Duration {
+	return time.Duration(atomic.LoadInt64(&n.latency))
+}
+
+func (n *Node) ejected(now int64) bool {
+	return atomic.LoadInt64(&n.ejectedUntil) > now
+}
+
+func (n *Node) observe(latency time.Duration) {
+	for {
+		old := atomic.LoadInt64(&n.latency)
+		val := int64(latency)
+		if old != 0 {
+			// 新样本的权重为1/4
+			val = old + (val-old)/4
+		}
+		if atomic.CompareAndSwapInt64(&n.latency, old, val) {
+			return
+		}
+	}
+}
+
+// Pool 维护服务实例列表, 使用Balancer选择实例, 并根据请求结果被动剔除连续失败的实例
+type Pool struct {
+	balancer      Balancer
+	maxFailures   int32
+	ejectDuration time.Duration
+
+	mu    sync.RWMutex
+	nodes []*Node
+}
+
+type PoolOption func(*Pool)
+
+// WithEjection 连续失败failures次的实例在duration内不会被选择, failures为0时不剔除实例
+// 所有实例都被剔除时忽略剔除状态, 从所有实例中选择
+func WithEjection(failures int, duration time.Duration) PoolOption {
+	return func(p *Pool) {
+		p.maxFailures = int32(failures)
+		p.ejectDuration = duration
+	}
+}
+
+// NewPool 默认连续失败5次的实例会被剔除30s
+func NewPool(b Balancer, opts ...PoolOption) *Pool {
+	p := &Pool{
+		balancer:      b,
+		maxFailures:   5,
+		ejectDuration: 30 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
+}
+
+// Update 更新实例列表, 已经存在的实例保留统计信息
+func (p *Pool) Update(addrs []string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	old := make(map[string]*Node, len(p.nodes))
+	for _, node := range p.nodes {
+		old[node.addr] = node
+	}
+
+	nodes := make([]*Node, 0, len(addrs))
+	for _, addr := range addrs {
+		if contains(nodes, addr) {
+			continue
+		}
+		node, ok := old[addr]
+		if !ok {
+			node = &Node{addr: addr}
+		}
+		nodes = append(nodes, node)
+	}
+	p.nodes = nodes
+}
+
+func contains(nodes []*Node, addr string) bool {
+	for _, node := range nodes {
+		if node.addr == addr {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Nodes 返回当前的实例列表
+func (p *Pool) Nodes() []*Node {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return append([]*Node(nil), p.nodes...)
+}
+
+// Pick 选择一个实例, 请求结束后需要调用DoneFunc
+func (p *Pool) Pick() (*Node, DoneFunc, error) {
+	p.mu.RLock()
+	nodes := p.nodes
+	p.mu.RUnlock()
+	if len(nodes) == 0 {
+		return nil, nil, ErrNoAvailable
+	}
+
+	now := time.Now().UnixNano()
+	healthy := make([]*Node, 0, len(nodes))
+	for _, node := range nodes {
+		if !node.ejected(now) {
+			healthy = append(healthy, node)
+		}
+	}
+	if len(healthy) == 0 {
+		healthy = nodes
+	}
+
+	node := p.balancer.Pick(healthy)
+	atomic.AddInt64(&node.inflight, 1)
+	start := time.Now()
+
+	return node, func(err error) {
+		atomic.AddInt64(&node.inflight, -1)
+		node.observe(time.Since(start))
+		if err == nil {
+			atomic.StoreInt32(&node.failures, 0)
+			return
+		}
+		if p.maxFailures > 0 && atomic.AddInt32(&node.failures, 1) >= p.maxFailures {
+			atomic.StoreInt32(&node.failures, 0)
+			atomic.StoreInt64(&node.ejectedUntil, time.Now().Add(p.ejectDuration).UnixNano())
+		}
+	}, nil
+}
+
+type roundRobin struct {
+	next uint64
+}
+
+// RoundRobin 轮询
+func RoundRobin() Balancer {
+	return &roundRobin{}
+}
+
+func (b *roundRobin) Pick(nodes []*Node) *Node {
+	n := atomic.AddUint64(&b.next, 1) - 1
+	return nodes[n%uint64(len(nodes))]
+}
+
+// lockedRand 并发安全的随机数生成器
+type lockedRand struct {
+	mu sync.Mutex
+	r  *rand.Rand
+}
+
+func newLockedRand() *lockedRand {
+	return &lockedRand{r: rand.New(rand.NewSource(time.Now().UnixNano()))}
+}
+
+func (r *lockedRand) Intn(n int) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.r.Intn(n)
+}
+
+type random struct {
+	r *lockedRand
+}
+
+// Random 随机选择
+func Random() Balancer {
+	return &random{r: newLockedRand()}
+}
+
+func (b *random) Pick(nodes []*Node) *Node {
+	return nodes[b.r.Intn(len(nodes))]
+}
+
+type p2c struct {
+	r *lockedRand
+}
+
+// P2C 随机选择两个实例, 使用平均延迟和正在处理的请求数较小的一个
+// 没有请求记录的实例延迟为0, 会被优先选择
+func P2C() Balancer {
+	return &p2c{r: newLockedRand()}
+}
+
+func (b *p2c) Pick(nodes []*Node) *Node {
+	if len(nodes) == 1 {
+		return nodes[0]
+	}
+
+	i := b.r.Intn(len(nodes))
+	j := b.r.Intn(len(nodes) - 1)
+	if j >= i {
+		j++
+	}
+	a, c := nodes[i], nodes[j]
+	if score(c) < score(a) {
+		return c
+	}
+
+	return a
+}
+
+func score(n *Node) float64 {
+	return float64(n.Latency()+1) * float64(n.Inflight()+1)
+}
@@ -0,0 +1,78 @@
+package balancer
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestPoolEjection(t *testing.T) {
+	p := NewPool(RoundRobin(), WithEjection(2, time.Minute))
+	if _, _, err := p.Pick(); err != ErrNoAvailable {
+		t.Fatalf("pick from empty pool = %v", err)
+	}
+
+	p.Update([]string{"a", "b", "a"})
+	if len(p.Nodes()) != 2 {
+		t.Fatalf("nodes = %d, want 2", len(p.Nodes()))
+	}
+
+	// b 连续失败两次后被剔除
+	for i := 0; i < 4; i++ {
+		node, done, err := p.Pick()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if node.Address() == "b" {
+			done(fmt.Errorf("connection refused"))
+		} else {
+			done(nil)
+		}
+	}
+	for i := 0; i < 4; i++ {
+		node, done, _ := p.Pick()
+		done(nil)
+		if node.Address() != "a" {
+			t.Fatalf("pick %s, want a after b ejected", node.Address())
+		}
+	}
+
+	// 更新实例列表时保留统计信息
+	p.Update([]string{"b"})
+	node, done, _ := p.Pick()
+	done(nil)
+	if node.Address() != "b" {
+		t.Fatalf("pick %s, want b when all nodes ejected", node.Address())
+	}
+}
+
+func TestBalancers(t *testing.T) {
+	nodes := []*Node{{addr: "a"}, {addr: "b"}, {addr: "c"}}
+
+	rr := RoundRobin()
+	for i := 0; i < 6; i++ {
+		if got := rr.Pick(nodes); got != nodes[i%3] {
+			t.Fatalf("round robin pick %d = %s", i, got.Address())
+		}
+	}
+
+	seen := make(map[string]bool)
+	r := Random()
+	for i := 0; i < 100; i++ {
+		seen[r.Pick(nodes).Address()] = true
+	}
+	if len(seen) != 3 {
+		t.Fatalf("random picked %v", seen)
+	}
+
+	// p2c 不会选择延迟最高的实例
+	nodes[0].observe(time.Millisecond)
+	nodes[1].observe(time.Millisecond)
+	nodes[2].observe(time.Second)
+	b := P2C()
+	for i := 0; i < 100; i++ {
+		if b.Pick(nodes) == nodes[2] {
+			t.Fatal("p2c picked the slowest node")
+		}
+	}
+}
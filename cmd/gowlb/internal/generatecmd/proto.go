@@ -29,10 +29,16 @@ var CmdGenProto = &cobra.Command{
 
 var (
 	protoPath = []string{"third_party", "."}
+	// 是否生成参数校验代码, 需要安装 protoc-gen-go-validate
+	genValidate bool
+	// 是否同时生成grpc服务端和客户端代码, 需要安装 protoc-gen-go-grpc
+	genGRPC bool
 )
 
 func init() {
 	CmdGenProto.Flags().StringSliceVarP(&protoPath, "proto_path", "p", protoPath, "specify proto_path")
+	CmdGenProto.Flags().BoolVar(&genValidate, "validate", false, "generate validate code by validate.proto rules")
+	CmdGenProto.Flags().BoolVar(&genGRPC, "grpc", false, "generate grpc server and client code alongside http code")
 }
 
 //  protoc --proto_path=third_party --proto_path=api --gogo_out=. --go-gin_out=. --go-error_out=. api/mangokit/v1/proto/mangokit.proto api/helloworld/v1/proto/greeter.proto
@@ -68,6 +74,13 @@ func GenerateProtos(dir string) error {
 	args = append(args, "--go_out=.")
 	args = append(args, "--go-gin_out=.")
 	args = append(args, "--go-error_out=.")
+	if genValidate {
+		args = append(args, "--go-validate_out=.")
+	}
+	if genGRPC {
+		// 不要求嵌入UnimplementedXXXServer, 同一个实现可以同时注册到http和grpc服务
+		args = append(args, "--go-grpc_out=.", "--go-grpc_opt=require_unimplemented_servers=false")
+	}
 	args = append(args, protos...)
 
 	cmd := exec.Command("protoc", args...)
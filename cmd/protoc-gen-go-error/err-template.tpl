@@ -24,4 +24,10 @@ func NewError{{ .CamelName }}(format string, args ...interface{}) errors.Error {
 	return errors.New(int32({{ .EnumName }}_{{ .Name }}), {{ .HTTPStatus }}, {{ .EnumName }}_{{ .Name }}.String(), fmt.Sprintf(format, args...))
 }
 
+// IsError{{ .CamelName }} 判断err是否为{{ .Name }}错误, 同样适用于客户端解析得到的错误
+func IsError{{ .CamelName }}(err error) bool {
+	e, ok := errors.AsError(err)
+	return ok && e.Code() == int32({{ .EnumName }}_{{ .Name }}) && e.Reason() == {{ .EnumName }}_{{ .Name }}.String()
+}
+
 {{ end }}
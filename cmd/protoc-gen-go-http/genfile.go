@@ -66,18 +66,29 @@ func genService(plugin *protogen.Plugin, file *protogen.File, g *protogen.Genera
 	// http service
 	sd := &ServiceDesc{
 		ServiceName: service.GoName,
+		FullName:    string(service.Desc.FullName()),
 		Comment:     comment,
 	}
 	sd.LowerServiceName = strings.ToLower(sd.ServiceName)
 
 	for _, method := range service.Methods {
-		if method.Desc.IsStreamingClient() || method.Desc.IsStreamingServer() {
+		// 只有客户端流式的方法无法映射到http请求
+		if method.Desc.IsStreamingClient() && !method.Desc.IsStreamingServer() {
 			continue
 		}
 		rule, ok := proto.GetExtension(method.Desc.Options(), annotations.E_Http).(*annotations.HttpRule)
 		if rule == nil || !ok {
 			continue
 		}
+		if method.Desc.IsStreamingServer() {
+			md := buildHTTPRule(g, service, method, rule)
+			if method.Desc.IsStreamingClient() {
+				checkWebSocketRule(method, md)
+				md.ClientStreams = true
+			}
+			sd.Streams = append(sd.Streams, md)
+			continue
+		}
 		sd.Methods = append(sd.Methods, buildHTTPRule(g, service, method, rule))
 
 		if len(method.Output.Fields) > 0 {
@@ -85,7 +96,7 @@ func genService(plugin *protogen.Plugin, file *protogen.File, g *protogen.Genera
 		}
 	}
 
-	if len(sd.Methods) != 0 {
+	if len(sd.Methods) != 0 || len(sd.Streams) != 0 {
 		g.P(sd.execute())
 	}
 }
@@ -135,6 +146,21 @@ func buildHTTPRule(g *protogen.GeneratedFile, service *protogen.Service, m *prot
 	md := buildMethodDesc(g, m)
 	md.Method = strings.ToUpper(method)
 	md.Path = path
+	md.Body = rule.Body
+
+	// body映射到某个字段时, 客户端只发送该字段
+	if md.Body != "" && md.Body != "*" {
+		for _, field := range m.Input.Fields {
+			if string(field.Desc.Name()) == md.Body {
+				md.BodyField = field.GoName
+				break
+			}
+		}
+		if md.BodyField == "" {
+			fmt.Fprintf(os.Stderr, "body field %s not found in %s\n", md.Body, m.Input.Desc.FullName())
+			os.Exit(1)
+		}
+	}
 	md.ServiceName = service.GoName
 	md.LowerServiceName = strings.ToLower(md.ServiceName)
 
@@ -151,6 +177,19 @@ func buildHTTPRule(g *protogen.GeneratedFile, service *protogen.Service, m *prot
 	return md
 }
 
+// checkWebSocketRule 双向流式方法通过WebSocket传输, 只能使用GET请求,
+// 并且请求消息在连接建立后发送, 路径中不能包含参数
+func checkWebSocketRule(m *protogen.Method, md *MethodDesc) {
+	if md.Method != http.MethodGet {
+		fmt.Fprintf(os.Stderr, "bidi streaming method %s must use get, got %s\n", m.Desc.FullName(), md.Method)
+		os.Exit(1)
+	}
+	if md.EncodeParam || md.Body != "" {
+		fmt.Fprintf(os.Stderr, "bidi streaming method %s can not declare path params or body\n", m.Desc.FullName())
+		os.Exit(1)
+	}
+}
+
 func validatePath(path string) bool {
 	if path == "" {
 		return false
@@ -177,6 +216,7 @@ func buildMethodDesc(g *protogen.GeneratedFile, m *protogen.Method) *MethodDesc
 
 	return &MethodDesc{
 		Name:           m.GoName,
+		OriginalName:   string(m.Desc.Name()),
 		Request:        g.QualifiedGoIdent(m.Input.GoIdent),
 		Reply:          g.QualifiedGoIdent(m.Output.GoIdent),
 		Comment:        comment,
@@ -188,7 +228,7 @@ func buildMethodDesc(g *protogen.GeneratedFile, m *protogen.Method) *MethodDesc
 func hasHTTPRule(services []*protogen.Service) bool {
 	for _, service := range services {
 		for _, method := range service.Methods {
-			if method.Desc.IsStreamingClient() || method.Desc.IsStreamingServer() {
+			if method.Desc.IsStreamingClient() && !method.Desc.IsStreamingServer() {
 				continue
 			}
 			rule, ok := proto.GetExtension(method.Desc.Options(), annotations.E_Http).(*annotations.HttpRule)
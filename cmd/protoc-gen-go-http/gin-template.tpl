@@ -1,3 +1,20 @@
+{{- define "clientParams"}}ctx context.Context{{if ne .InputFieldLen 0}}, req *{{.Request}}{{end}}, opts ...http.CallOption{{end}}
+
+{{- define "clientResults"}}{{if ne .OutputFieldLen 0}}(*{{.Reply}}, error){{else}}error{{end}}{{end}}
+
+{{- define "streamParams"}}ctx context.Context{{if not (or .ClientStreams (eq .InputFieldLen 0))}}, req *{{.Request}}{{end}}, opts ...http.CallOption{{end}}
+
+{{- define "path"}}
+    {{- if and .EncodeParam .EncodeForm}}
+    path, err := http.EncodeURL("{{.Path}}", req, true)
+    {{- else if .EncodeParam}}
+    path, err := http.EncodeURL("{{.Path}}", req, false)
+    {{- else if .EncodeForm}}
+    path, err := http.EncodeURLFromForm("{{.Path}}", req)
+    {{- else}}
+    path := "{{.Path}}"
+    {{- end}}
+{{- end}}
 
 {{- if ne .Comment ""}}
 {{.Comment}}
@@ -17,9 +34,19 @@ type {{.ServiceName}}HTTPService interface {
         {{.Name}}(context.Context, *{{.Request}}) (*{{.Reply}}, error)
     {{- end}}
 {{- end}}
+{{- range .Streams}}
+	{{- if ne .Comment ""}}
+	{{.Comment}}
+	{{- end}}
+	{{- if or .ClientStreams (eq .InputFieldLen 0)}}
+        {{.Name}}(context.Context, {{.ServiceName}}_{{.Name}}HTTPServer) error
+    {{- else}}
+        {{.Name}}(context.Context, *{{.Request}}, {{.ServiceName}}_{{.Name}}HTTPServer) error
+    {{- end}}
+{{- end}}
 }
 
-func Register{{.ServiceName}}HTTPService(server *http.Server, svc {{.ServiceName}}HTTPService) {
+func Register{{.ServiceName}}HTTPService(server http.ServiceRegistrar, svc {{.ServiceName}}HTTPService) {
     server.RegisterService(_{{.ServiceName}}HTTPService_serviceDesc, svc)
 }
 
@@ -66,6 +93,64 @@ func _{{.ServiceName}}_{{.Name}}_HTTP_Handler(svc interface{}, ctx context.Conte
 }
 {{end}}
 
+{{range .Streams}}
+func _{{.ServiceName}}_{{.Name}}_HTTP_Stream_Handler(svc interface{}, ctx context.Context, dec func(interface{}) error, stream http.ServerStream, middleware http.Middleware) error {
+    {{- if and (not .ClientStreams) (ne .InputFieldLen 0)}}
+    in := new({{.Request}})
+    if err := dec(in); err != nil {
+        return err
+    }
+    {{- end}}
+
+    handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+    {{- if or .ClientStreams (eq .InputFieldLen 0)}}
+        return nil, svc.({{.ServiceName}}HTTPService).{{.Name}}(ctx, &{{.LowerServiceName}}{{.Name}}HTTPServer{stream})
+    {{- else}}
+        return nil, svc.({{.ServiceName}}HTTPService).{{.Name}}(ctx, in, &{{.LowerServiceName}}{{.Name}}HTTPServer{stream})
+    {{- end}}
+    }
+    if middleware == nil {
+        middleware = func(ctx context.Context, req interface{}, handler http.Handler) (interface{}, error) {
+            return handler(ctx, req)
+        }
+    }
+
+    {{if or .ClientStreams (eq .InputFieldLen 0) -}}
+    _, err := middleware(ctx, nil, handler)
+    {{- else -}}
+    _, err := middleware(ctx, in, handler)
+    {{- end}}
+    return err
+}
+
+type {{.ServiceName}}_{{.Name}}HTTPServer interface {
+    Send(*{{.Reply}}) error
+    {{- if .ClientStreams}}
+    Recv() (*{{.Request}}, error)
+    {{- end}}
+    Context() context.Context
+}
+
+type {{.LowerServiceName}}{{.Name}}HTTPServer struct {
+    http.ServerStream
+}
+
+func (x *{{.LowerServiceName}}{{.Name}}HTTPServer) Send(m *{{.Reply}}) error {
+    return x.ServerStream.SendMsg(m)
+}
+{{- if .ClientStreams}}
+
+func (x *{{.LowerServiceName}}{{.Name}}HTTPServer) Recv() (*{{.Request}}, error) {
+    m := new({{.Request}})
+    if err := x.ServerStream.RecvMsg(m); err != nil {
+        return nil, err
+    }
+
+    return m, nil
+}
+{{- end}}
+{{end}}
+
 type {{.ServiceName}}HTTPClient interface {
 {{- range .Methods}}
 	{{- if and (eq .InputFieldLen 0) (eq .OutputFieldLen 0)}}
@@ -78,6 +163,13 @@ type {{.ServiceName}}HTTPClient interface {
         {{.Name}}(ctx context.Context, req *{{.Request}}, opts ...http.CallOption) (*{{.Reply}}, error)
     {{- end}}
 {{- end}}
+{{- range .Streams}}
+    {{- if or .ClientStreams (eq .InputFieldLen 0)}}
+        {{.Name}}(ctx context.Context, opts ...http.CallOption) ({{.ServiceName}}_{{.Name}}HTTPClient, error)
+    {{- else}}
+        {{.Name}}(ctx context.Context, req *{{.Request}}, opts ...http.CallOption) ({{.ServiceName}}_{{.Name}}HTTPClient, error)
+    {{- end}}
+{{- end}}
 }
 
 type {{.LowerServiceName}}HTTPClient struct {
@@ -101,26 +193,27 @@ func (c *{{.LowerServiceName}}HTTPClient) {{.Name}}(ctx context.Context, opts ..
     {{- if ne .OutputFieldLen 0}}
 	reply := new({{.Reply}})
     {{- end}}
-    {{- if and .EncodeParam .EncodeForm}}
-	pattern := "{{.Path}}"
-    path := http.EncodeURL(pattern, req, true)
-    {{- else if .EncodeParam}}
-    pattern := "{{.Path}}"
-    path := http.EncodeURL(pattern, req, false)
-    {{- else if .EncodeForm}}
-    pattern := "{{.Path}}"
-    path := http.EncodeURLFromForm(pattern, req)
-    {{- else}}
-    path := "{{.Path}}"
+    {{- template "path" .}}
+    {{- $assign := ":="}}
+    {{- if or .EncodeParam .EncodeForm}}
+    {{- $assign = "="}}
+    if err != nil {
+        return {{if ne .OutputFieldLen 0}}nil, {{end}}err
+    }
     {{- end}}
-	{{- if and (ne .InputFieldLen 0) (ne .OutputFieldLen 0)}}
-    _, err := c.cc.Invoke(ctx, "{{.Method}}", path, req, reply, opts...)
+    opts = append([]http.CallOption{http.OperationCallOption("/{{$.FullName}}/{{.OriginalName}}")}, opts...)
+	{{- if and (ne .BodyField "") (ne .OutputFieldLen 0)}}
+    _, err {{$assign}} c.cc.Invoke(ctx, "{{.Method}}", path, req.{{.BodyField}}, reply, opts...)
+    {{- else if ne .BodyField ""}}
+    _, err {{$assign}} c.cc.Invoke(ctx, "{{.Method}}", path, req.{{.BodyField}}, nil, opts...)
+	{{- else if and (ne .InputFieldLen 0) (ne .OutputFieldLen 0)}}
+    _, err {{$assign}} c.cc.Invoke(ctx, "{{.Method}}", path, req, reply, opts...)
     {{- else if ne .InputFieldLen 0}}
-    _, err := c.cc.Invoke(ctx, "{{.Method}}", path, req, nil, opts...)
+    _, err {{$assign}} c.cc.Invoke(ctx, "{{.Method}}", path, req, nil, opts...)
     {{- else if ne .OutputFieldLen 0}}
-    _, err := c.cc.Invoke(ctx, "{{.Method}}", path, nil, reply, opts...)
+    _, err {{$assign}} c.cc.Invoke(ctx, "{{.Method}}", path, nil, reply, opts...)
     {{- else}}
-    _, err := c.cc.Invoke(ctx, "{{.Method}}", path, nil, nil, opts...)
+    _, err {{$assign}} c.cc.Invoke(ctx, "{{.Method}}", path, nil, nil, opts...)
     {{- end}}
 	
     {{if ne .OutputFieldLen 0}}
@@ -131,15 +224,141 @@ func (c *{{.LowerServiceName}}HTTPClient) {{.Name}}(ctx context.Context, opts ..
 }
 {{end}}
 
+{{range .Streams}}
+{{- if or .ClientStreams (eq .InputFieldLen 0) -}}
+func (c *{{.LowerServiceName}}HTTPClient) {{.Name}}(ctx context.Context, opts ...http.CallOption) ({{.ServiceName}}_{{.Name}}HTTPClient, error) {
+{{- else -}}
+func (c *{{.LowerServiceName}}HTTPClient) {{.Name}}(ctx context.Context, req *{{.Request}}, opts ...http.CallOption) ({{.ServiceName}}_{{.Name}}HTTPClient, error) {
+{{- end}}
+    {{- if .ClientStreams}}
+    opts = append([]http.CallOption{http.OperationCallOption("/{{$.FullName}}/{{.OriginalName}}")}, opts...)
+    stream, err := c.cc.NewWebSocket(ctx, "{{.Path}}", opts...)
+    {{- else}}
+    {{- template "path" .}}
+    {{- if or .EncodeParam .EncodeForm}}
+    if err != nil {
+        return nil, err
+    }
+    {{- end}}
+    opts = append([]http.CallOption{http.OperationCallOption("/{{$.FullName}}/{{.OriginalName}}")}, opts...)
+    {{- end}}
+    {{- if .ClientStreams}}
+    {{- else if ne .BodyField ""}}
+    stream, err := c.cc.NewStream(ctx, "{{.Method}}", path, req.{{.BodyField}}, opts...)
+    {{- else if ne .InputFieldLen 0}}
+    stream, err := c.cc.NewStream(ctx, "{{.Method}}", path, req, opts...)
+    {{- else}}
+    stream, err := c.cc.NewStream(ctx, "{{.Method}}", path, nil, opts...)
+    {{- end}}
+    if err != nil {
+        return nil, err
+    }
+
+    return &{{.LowerServiceName}}{{.Name}}HTTPClient{stream}, nil
+}
+
+// {{.ServiceName}}_{{.Name}}HTTPClient Recv 在流结束时返回io.EOF, 使用完毕后需要调用Close
+type {{.ServiceName}}_{{.Name}}HTTPClient interface {
+    {{- if .ClientStreams}}
+    Send(*{{.Request}}) error
+    CloseSend() error
+    {{- end}}
+    Recv() (*{{.Reply}}, error)
+    Close() error
+}
+
+type {{.LowerServiceName}}{{.Name}}HTTPClient struct {
+    http.ClientStream
+}
+
+{{if .ClientStreams -}}
+func (x *{{.LowerServiceName}}{{.Name}}HTTPClient) Send(m *{{.Request}}) error {
+    return x.ClientStream.SendMsg(m)
+}
+
+{{end -}}
+func (x *{{.LowerServiceName}}{{.Name}}HTTPClient) Recv() (*{{.Reply}}, error) {
+    m := new({{.Reply}})
+    if err := x.ClientStream.RecvMsg(m); err != nil {
+        return nil, err
+    }
+
+    return m, nil
+}
+{{end}}
+
+// Fake{{.ServiceName}}HTTPClient {{.ServiceName}}HTTPClient的测试实现, 通过XXXFunc设置方法的行为,
+// 没有设置的方法返回 http.ErrFakeNotImplemented, 所有调用都会被记录, 可以通过Calls和CallsTo检查
+type Fake{{.ServiceName}}HTTPClient struct {
+    http.FakeRecorder
+{{range .Methods}}
+    {{.Name}}Func func({{template "clientParams" .}}) {{template "clientResults" .}}
+{{- end}}
+{{- range .Streams}}
+    {{.Name}}Func func({{template "streamParams" .}}) ({{.ServiceName}}_{{.Name}}HTTPClient, error)
+{{- end}}
+}
+
+var _ {{.ServiceName}}HTTPClient = (*Fake{{.ServiceName}}HTTPClient)(nil)
+
+{{range .Methods}}
+func (f *Fake{{.ServiceName}}HTTPClient) {{.Name}}({{template "clientParams" .}}) {{template "clientResults" .}} {
+    f.RecordCall("{{.Name}}", "/{{$.FullName}}/{{.OriginalName}}", {{if ne .InputFieldLen 0}}req{{else}}nil{{end}})
+    if f.{{.Name}}Func == nil {
+        return {{if ne .OutputFieldLen 0}}nil, {{end}}http.ErrFakeNotImplemented
+    }
+
+    return f.{{.Name}}Func(ctx, {{if ne .InputFieldLen 0}}req, {{end}}opts...)
+}
+{{end}}
+
+{{range .Streams}}
+func (f *Fake{{.ServiceName}}HTTPClient) {{.Name}}({{template "streamParams" .}}) ({{.ServiceName}}_{{.Name}}HTTPClient, error) {
+    {{- if or .ClientStreams (eq .InputFieldLen 0)}}
+    f.RecordCall("{{.Name}}", "/{{$.FullName}}/{{.OriginalName}}", nil)
+    {{- else}}
+    f.RecordCall("{{.Name}}", "/{{$.FullName}}/{{.OriginalName}}", req)
+    {{- end}}
+    if f.{{.Name}}Func == nil {
+        return nil, http.ErrFakeNotImplemented
+    }
+
+    {{if or .ClientStreams (eq .InputFieldLen 0) -}}
+    return f.{{.Name}}Func(ctx, opts...)
+    {{- else -}}
+    return f.{{.Name}}Func(ctx, req, opts...)
+    {{- end}}
+}
+{{end}}
+
 var _{{.ServiceName}}HTTPService_serviceDesc = &http.ServiceDesc{
+	ServiceName: "{{.FullName}}",
 	HandlerType: (*{{.ServiceName}}HTTPService)(nil),
 	Methods: []http.MethodDesc{
 	{{- range .Methods}}
 		{
+			Name:    "{{.OriginalName}}",
 			Method:  "{{.Method}}",
 			Path:    "{{.Path}}",
+			Body:    "{{.Body}}",
 			Handler: _{{.ServiceName}}_{{.Name}}_HTTP_Handler,
 		},
 	{{- end}}
 	},
+	{{- if .Streams}}
+	Streams: []http.StreamDesc{
+	{{- range .Streams}}
+		{
+			Name:    "{{.OriginalName}}",
+			Method:  "{{.Method}}",
+			Path:    "{{.Path}}",
+			Body:    "{{.Body}}",
+			Handler: _{{.ServiceName}}_{{.Name}}_HTTP_Stream_Handler,
+			{{- if .ClientStreams}}
+			ClientStreams: true,
+			{{- end}}
+		},
+	{{- end}}
+	},
+	{{- end}}
 }
@@ -14,14 +14,17 @@ var TextTemplate string
 
 type ServiceDesc struct {
 	ServiceName      string
+	FullName         string // proto service全名
 	LowerServiceName string
 	Comment          string
 	Methods          []*MethodDesc
+	Streams          []*MethodDesc // 服务端流式方法
 	ImportSerialize  bool
 }
 
 type MethodDesc struct {
 	Name           string // 方法名
+	OriginalName   string // proto中定义的方法名
 	Request        string // 请求参数名
 	Reply          string // 响应参数名
 	ServiceName    string // 所属service名
@@ -30,8 +33,12 @@ type MethodDesc struct {
 	OutputFieldLen int    // 输出参数字段数量
 
 	// http rule
-	Path   string // 请求路径
-	Method string // 请求方法
+	Path      string // 请求路径
+	Method    string // 请求方法
+	Body      string // 请求体映射的字段, "*" 表示整个请求参数
+	BodyField string // Body对应的Go字段名
+
+	ClientStreams bool // 双向流式方法, 通过WebSocket传输
 
 	LowerServiceName string // 小写service名
 	EncodeParam      bool
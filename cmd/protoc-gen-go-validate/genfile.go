@@ -0,0 +1,246 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/envoyproxy/protoc-gen-validate/validate"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+const (
+	validatePackage = protogen.GoImportPath("github.com/mangohow/gowlb/validate")
+	bytesPackage    = protogen.GoImportPath("bytes")
+	stringsPackage  = protogen.GoImportPath("strings")
+	utf8Package     = protogen.GoImportPath("unicode/utf8")
+)
+
+func generateFile(gen *protogen.Plugin, file *protogen.File) *protogen.GeneratedFile {
+	if len(file.Messages) == 0 {
+		return nil
+	}
+
+	filename := file.GeneratedFilenamePrefix + ".pb.validate.go"
+	g := gen.NewGeneratedFile(filename, file.GoImportPath)
+	g.P("// Code generated by protoc-gen-go-validate. DO NOT EDIT.")
+	g.P("// versions:")
+	g.P(fmt.Sprintf("// - protoc-gen-go-validate %s", version))
+	g.P("// - protoc                 ", protocVersion(gen))
+	if file.Proto.GetOptions().GetDeprecated() {
+		g.P("// ", file.Desc.Path(), " is a deprecated file.")
+	} else {
+		g.P("// source: ", file.Desc.Path())
+	}
+	g.P()
+	g.P("package ", file.GoPackageName)
+	g.P()
+
+	for _, message := range file.Messages {
+		genMessage(g, message)
+	}
+
+	return g
+}
+
+func genMessage(g *protogen.GeneratedFile, message *protogen.Message) {
+	if message.Desc.IsMapEntry() {
+		return
+	}
+
+	for _, nested := range message.Messages {
+		genMessage(g, nested)
+	}
+
+	// ignored 不生成Validate方法
+	if proto.GetExtension(message.Desc.Options(), validate.E_Ignored).(bool) {
+		return
+	}
+
+	g.P("// Validate 根据validate.proto中声明的规则校验", message.GoIdent.GoName, ", 返回所有不满足规则的字段")
+	g.P("func (m *", message.GoIdent, ") Validate() error {")
+	// disabled 不进行任何校验
+	if proto.GetExtension(message.Desc.Options(), validate.E_Disabled).(bool) {
+		g.P("return nil")
+		g.P("}")
+		g.P()
+		return
+	}
+
+	g.P("if m == nil {")
+	g.P("return nil")
+	g.P("}")
+	g.P()
+	g.P("var errs ", validatePackage.Ident("ValidationError"))
+	g.P()
+
+	for _, oneof := range message.Oneofs {
+		if oneof.Desc.IsSynthetic() || !proto.GetExtension(oneof.Desc.Options(), validate.E_Required).(bool) {
+			continue
+		}
+		g.P("if m.", oneof.GoName, " == nil {")
+		g.P(`errs.Add(`, strconv.Quote(string(oneof.Desc.Name())), `, "value is required")`)
+		g.P("}")
+		g.P()
+	}
+
+	for _, field := range message.Fields {
+		genField(g, message, field)
+	}
+
+	g.P("return errs.Err()")
+	g.P("}")
+	g.P()
+}
+
+func genField(g *protogen.GeneratedFile, message *protogen.Message, field *protogen.Field) {
+	rules, _ := proto.GetExtension(field.Desc.Options(), validate.E_Rules).(*validate.FieldRules)
+	name := strconv.Quote(string(field.Desc.Name()))
+	value := "m.Get" + field.GoName + "()"
+
+	switch {
+	case field.Desc.IsMap():
+		genMapField(g, field, rules, name, value)
+	case field.Desc.IsList():
+		genListField(g, field, rules, name, value)
+	case field.Oneof != nil && field.Oneof.Desc.IsSynthetic():
+		// proto3 optional 只有在设置了值时才进行校验
+		g.P("if m.", field.GoName, " != nil {")
+		genValue(g, field, field.Desc, rules, name, value)
+		g.P("}")
+		g.P()
+	case field.Oneof != nil:
+		// oneof 只有在选择了该字段时才进行校验
+		g.P("if _, ok := m.", field.Oneof.GoName, ".(*", field.GoIdent, "); ok {")
+		genValue(g, field, field.Desc, rules, name, value)
+		g.P("}")
+		g.P()
+	default:
+		genValue(g, field, field.Desc, rules, name, value)
+	}
+}
+
+func genMapField(g *protogen.GeneratedFile, field *protogen.Field, rules *validate.FieldRules, name, value string) {
+	mapRules := rules.GetMap()
+	if mapRules != nil && rules.GetType() != nil {
+		if mapRules.GetIgnoreEmpty() {
+			g.P("if len(", value, ") > 0 {")
+		}
+		if mapRules.MinPairs != nil {
+			g.P("if len(", value, ") < ", mapRules.GetMinPairs(), " {")
+			g.P("errs.Add(", name, `, "value must contain at least `, mapRules.GetMinPairs(), ` pair(s)")`)
+			g.P("}")
+		}
+		if mapRules.MaxPairs != nil {
+			g.P("if len(", value, ") > ", mapRules.GetMaxPairs(), " {")
+			g.P("errs.Add(", name, `, "value must contain no more than `, mapRules.GetMaxPairs(), ` pair(s)")`)
+			g.P("}")
+		}
+		if mapRules.GetIgnoreEmpty() {
+			g.P("}")
+		}
+	} else if rules.GetType() != nil {
+		mismatch(field, rules)
+	}
+
+	keyField, valueField := field.Message.Fields[0], field.Message.Fields[1]
+	keyRules, valueRules := mapRules.GetKeys(), mapRules.GetValues()
+	validateValue := valueField.Desc.Kind() == protoreflect.MessageKind && !valueRules.GetMessage().GetSkip()
+	if keyRules.GetType() == nil && valueRules.GetType() == nil && !validateValue {
+		g.P()
+		return
+	}
+
+	path := g.QualifiedGoIdent(validatePackage.Ident("Key")) + "(" + name + ", key)"
+	if valueRules.GetType() == nil && !validateValue {
+		g.P("for key := range ", value, " {")
+		genValue(g, keyField, keyField.Desc, keyRules, path, "key")
+	} else {
+		g.P("for key, value := range ", value, " {")
+		genValue(g, keyField, keyField.Desc, keyRules, path, "key")
+		genValue(g, valueField, valueField.Desc, valueRules, path, "value")
+	}
+	g.P("}")
+	g.P()
+}
+
+func genListField(g *protogen.GeneratedFile, field *protogen.Field, rules *validate.FieldRules, name, value string) {
+	listRules := rules.GetRepeated()
+	if listRules == nil && rules.GetType() != nil {
+		mismatch(field, rules)
+	}
+
+	if listRules != nil {
+		if listRules.GetIgnoreEmpty() {
+			g.P("if len(", value, ") > 0 {")
+		}
+		if listRules.MinItems != nil {
+			g.P("if len(", value, ") < ", listRules.GetMinItems(), " {")
+			g.P("errs.Add(", name, `, "value must contain at least `, listRules.GetMinItems(), ` item(s)")`)
+			g.P("}")
+		}
+		if listRules.MaxItems != nil {
+			g.P("if len(", value, ") > ", listRules.GetMaxItems(), " {")
+			g.P("errs.Add(", name, `, "value must contain no more than `, listRules.GetMaxItems(), ` item(s)")`)
+			g.P("}")
+		}
+		if listRules.GetUnique() && field.Desc.Kind() != protoreflect.MessageKind {
+			g.P("seen", field.GoName, " := make(map[", uniqueKeyType(g, field), "]struct{}, len(", value, "))")
+			g.P("for _, item := range ", value, " {")
+			key := "item"
+			if field.Desc.Kind() == protoreflect.BytesKind {
+				key = "string(item)"
+			}
+			g.P("if _, ok := seen", field.GoName, "[", key, "]; ok {")
+			g.P("errs.Add(", name, `, "repeated value must contain unique items")`)
+			g.P("break")
+			g.P("}")
+			g.P("seen", field.GoName, "[", key, "] = struct{}{}")
+			g.P("}")
+		}
+	}
+
+	itemRules := listRules.GetItems()
+	validateItem := field.Desc.Kind() == protoreflect.MessageKind && !itemRules.GetMessage().GetSkip()
+	if itemRules.GetType() != nil || validateItem {
+		g.P("for i, item := range ", value, " {")
+		genValue(g, field, field.Desc, itemRules, g.QualifiedGoIdent(validatePackage.Ident("Index"))+"("+name+", i)", "item")
+		g.P("}")
+	}
+	if listRules.GetIgnoreEmpty() {
+		g.P("}")
+	}
+	g.P()
+}
+
+func uniqueKeyType(g *protogen.GeneratedFile, field *protogen.Field) string {
+	switch field.Desc.Kind() {
+	case protoreflect.BytesKind:
+		return "string"
+	case protoreflect.EnumKind:
+		return g.QualifiedGoIdent(field.Enum.GoIdent)
+	}
+
+	return goScalarType(field.Desc.Kind())
+}
+
+func mismatch(field *protogen.Field, rules *validate.FieldRules) {
+	fmt.Fprintf(os.Stderr, "\u001B[31mERROR\u001B[m: rule %s mismatch field %s of type %s\n",
+		rules.ProtoReflect().WhichOneof(rules.ProtoReflect().Descriptor().Oneofs().ByName("type")).Name(),
+		field.Desc.FullName(), field.Desc.Kind())
+	os.Exit(1)
+}
+
+func protocVersion(gen *protogen.Plugin) string {
+	v := gen.Request.GetCompilerVersion()
+	if v == nil {
+		return "(unknown)"
+	}
+	var suffix string
+	if s := v.GetSuffix(); s != "" {
+		suffix = "-" + s
+	}
+	return fmt.Sprintf("v%d.%d.%d%s", v.GetMajor(), v.GetMinor(), v.GetPatch(), suffix)
+}
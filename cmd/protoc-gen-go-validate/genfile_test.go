@@ -0,0 +1,147 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/envoyproxy/protoc-gen-validate/validate"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+func stringRules(r *validate.StringRules) *validate.FieldRules {
+	return &validate.FieldRules{Type: &validate.FieldRules_String_{String_: r}}
+}
+
+func field(name string, number int32, label descriptorpb.FieldDescriptorProto_Label, typ descriptorpb.FieldDescriptorProto_Type,
+	typeName string, rules *validate.FieldRules) *descriptorpb.FieldDescriptorProto {
+	f := &descriptorpb.FieldDescriptorProto{
+		Name:     proto.String(name),
+		JsonName: proto.String(name),
+		Number:   proto.Int32(number),
+		Label:    label.Enum(),
+		Type:     typ.Enum(),
+	}
+	if typeName != "" {
+		f.TypeName = proto.String(typeName)
+	}
+	if rules != nil {
+		f.Options = &descriptorpb.FieldOptions{}
+		proto.SetExtension(f.Options, validate.E_Rules, rules)
+	}
+
+	return f
+}
+
+func mapEntry(name string) *descriptorpb.DescriptorProto {
+	optional, str := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL, descriptorpb.FieldDescriptorProto_TYPE_STRING
+	return &descriptorpb.DescriptorProto{
+		Name:    proto.String(name),
+		Options: &descriptorpb.MessageOptions{MapEntry: proto.Bool(true)},
+		Field: []*descriptorpb.FieldDescriptorProto{
+			field("key", 1, optional, str, "", nil),
+			field("value", 2, optional, str, "", nil),
+		},
+	}
+}
+
+// generate 使用手动构造的proto文件运行插件, 返回生成的代码
+func generate(t *testing.T, file *descriptorpb.FileDescriptorProto) string {
+	req := &pluginpb.CodeGeneratorRequest{FileToGenerate: []string{file.GetName()}}
+	for _, fd := range []protoreflect.FileDescriptor{
+		descriptorpb.File_google_protobuf_descriptor_proto,
+		durationpb.File_google_protobuf_duration_proto,
+		timestamppb.File_google_protobuf_timestamp_proto,
+		validate.File_validate_validate_proto,
+	} {
+		req.ProtoFile = append(req.ProtoFile, protodesc.ToFileDescriptorProto(fd))
+	}
+	req.ProtoFile = append(req.ProtoFile, file)
+
+	gen, err := protogen.Options{}.New(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range gen.Files {
+		if f.Generate {
+			generateFile(gen, f)
+		}
+	}
+
+	resp := gen.Response()
+	if resp.Error != nil {
+		t.Fatal(resp.GetError())
+	}
+	if len(resp.File) != 1 {
+		t.Fatalf("generated %d files, want 1", len(resp.File))
+	}
+
+	return resp.File[0].GetContent()
+}
+
+func TestGenerateListAndMapFields(t *testing.T) {
+	optional := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL
+	repeated := descriptorpb.FieldDescriptorProto_LABEL_REPEATED
+	str := descriptorpb.FieldDescriptorProto_TYPE_STRING
+	message := descriptorpb.FieldDescriptorProto_TYPE_MESSAGE
+
+	file := &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("test/v1/test.proto"),
+		Package:    proto.String("test.v1"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"validate/validate.proto"},
+		Options:    &descriptorpb.FileOptions{GoPackage: proto.String("example.com/test/v1;testv1")},
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("Item"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("name", 1, optional, str, "", stringRules(&validate.StringRules{MinLen: proto.Uint64(1)})),
+				},
+			},
+			{
+				Name: proto.String("Request"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("tags", 1, repeated, str, "", nil),
+					field("names", 2, repeated, str, "", &validate.FieldRules{Type: &validate.FieldRules_Repeated{Repeated: &validate.RepeatedRules{
+						MinItems: proto.Uint64(1),
+						Unique:   proto.Bool(true),
+						Items:    stringRules(&validate.StringRules{MinLen: proto.Uint64(2)}),
+					}}}),
+					field("items", 3, repeated, message, ".test.v1.Item", nil),
+					field("labels", 4, repeated, message, ".test.v1.Request.LabelsEntry", nil),
+					field("attrs", 5, repeated, message, ".test.v1.Request.AttrsEntry", &validate.FieldRules{Type: &validate.FieldRules_Map{Map: &validate.MapRules{
+						MinPairs: proto.Uint64(1),
+						Keys:     stringRules(&validate.StringRules{MinLen: proto.Uint64(3)}),
+					}}}),
+				},
+				NestedType: []*descriptorpb.DescriptorProto{mapEntry("LabelsEntry"), mapEntry("AttrsEntry")},
+			},
+		},
+	}
+
+	content := generate(t, file)
+	for _, want := range []string{
+		"len(m.GetNames()) < 1",
+		"seenNames",
+		`validate.Index("names", i)`,
+		"for i, item := range m.GetItems()",
+		"len(m.GetAttrs()) < 1",
+		`validate.Key("attrs", key)`,
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("generated code does not contain %q:\n%s", want, content)
+		}
+	}
+	// 没有规则的字段不生成校验代码
+	for _, unwanted := range []string{"m.GetTags()", "m.GetLabels()"} {
+		if strings.Contains(content, unwanted) {
+			t.Errorf("generated code contains %q:\n%s", unwanted, content)
+		}
+	}
+}
@@ -0,0 +1,8 @@
+module github.com/mangohow/gowlb/cmd/protoc-gen-go-validate
+
+go 1.20
+
+require (
+	github.com/envoyproxy/protoc-gen-validate v1.0.4
+	google.golang.org/protobuf v1.34.2
+)
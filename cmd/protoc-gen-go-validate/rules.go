@@ -0,0 +1,414 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/envoyproxy/protoc-gen-validate/validate"
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// genValue 生成单个值的校验代码, path为字段名表达式, value为值表达式
+func genValue(g *protogen.GeneratedFile, field *protogen.Field, desc protoreflect.FieldDescriptor, rules *validate.FieldRules, path, value string) {
+	if desc.Kind() == protoreflect.MessageKind || desc.Kind() == protoreflect.GroupKind {
+		genMessageRules(g, field, rules, path, value)
+		return
+	}
+
+	if rules.GetType() == nil {
+		return
+	}
+
+	switch r := rules.GetType().(type) {
+	case *validate.FieldRules_String_:
+		checkKind(field, rules, desc.Kind() == protoreflect.StringKind)
+		genStringRules(g, r.String_, path, value)
+	case *validate.FieldRules_Bytes:
+		checkKind(field, rules, desc.Kind() == protoreflect.BytesKind)
+		genBytesRules(g, r.Bytes, path, value)
+	case *validate.FieldRules_Bool:
+		checkKind(field, rules, desc.Kind() == protoreflect.BoolKind)
+		if r.Bool.Const != nil {
+			g.P("if ", value, " != ", r.Bool.GetConst(), " {")
+			g.P("errs.Add(", path, `, "value must equal `, r.Bool.GetConst(), `")`)
+			g.P("}")
+		}
+	case *validate.FieldRules_Enum:
+		checkKind(field, rules, desc.Kind() == protoreflect.EnumKind)
+		genEnumRules(g, field, r.Enum, path, value)
+	default:
+		ruleMsg := rules.ProtoReflect()
+		fd := ruleMsg.WhichOneof(ruleMsg.Descriptor().Oneofs().ByName("type"))
+		checkKind(field, rules, string(fd.Name()) == desc.Kind().String())
+		genNumericRules(g, ruleMsg.Get(fd).Message(), path, value)
+	}
+}
+
+func checkKind(field *protogen.Field, rules *validate.FieldRules, ok bool) {
+	if !ok {
+		mismatch(field, rules)
+	}
+}
+
+func genMessageRules(g *protogen.GeneratedFile, field *protogen.Field, rules *validate.FieldRules, path, value string) {
+	if rules.GetType() != nil {
+		fmt.Fprintf(os.Stderr, "\u001B[33mWARN\u001B[m: rule of field %s is not supported, skipped\n", field.Desc.FullName())
+	}
+
+	messageRules := rules.GetMessage()
+	if messageRules.GetRequired() {
+		g.P("if ", value, " == nil {")
+		g.P("errs.Add(", path, `, "value is required")`)
+		g.P("}")
+	}
+	if !messageRules.GetSkip() {
+		g.P("errs.Nested(", path, ", ", value, ")")
+	}
+}
+
+func genStringRules(g *protogen.GeneratedFile, r *validate.StringRules, path, value string) {
+	if r.GetIgnoreEmpty() {
+		g.P("if ", value, ` != "" {`)
+	}
+
+	runeCount := g.QualifiedGoIdent(utf8Package.Ident("RuneCountInString")) + "(" + value + ")"
+	check := func(cond, desc string) {
+		g.P("if ", cond, " {")
+		g.P("errs.Add(", path, ", ", strconv.Quote(desc), ")")
+		g.P("}")
+	}
+
+	if r.Const != nil {
+		check(value+" != "+strconv.Quote(r.GetConst()), "value must equal "+r.GetConst())
+	}
+	if r.Len != nil {
+		check(fmt.Sprintf("%s != %d", runeCount, r.GetLen()), fmt.Sprintf("value length must be %d runes", r.GetLen()))
+	}
+	if r.MinLen != nil {
+		check(fmt.Sprintf("%s < %d", runeCount, r.GetMinLen()), fmt.Sprintf("value length must be at least %d runes", r.GetMinLen()))
+	}
+	if r.MaxLen != nil {
+		check(fmt.Sprintf("%s > %d", runeCount, r.GetMaxLen()), fmt.Sprintf("value length must be at most %d runes", r.GetMaxLen()))
+	}
+	if r.LenBytes != nil {
+		check(fmt.Sprintf("len(%s) != %d", value, r.GetLenBytes()), fmt.Sprintf("value length must be %d bytes", r.GetLenBytes()))
+	}
+	if r.MinBytes != nil {
+		check(fmt.Sprintf("len(%s) < %d", value, r.GetMinBytes()), fmt.Sprintf("value length must be at least %d bytes", r.GetMinBytes()))
+	}
+	if r.MaxBytes != nil {
+		check(fmt.Sprintf("len(%s) > %d", value, r.GetMaxBytes()), fmt.Sprintf("value length must be at most %d bytes", r.GetMaxBytes()))
+	}
+	if r.Pattern != nil {
+		mustCompile(r.GetPattern())
+		check("!"+g.QualifiedGoIdent(validatePackage.Ident("MatchString"))+"("+strconv.Quote(r.GetPattern())+", "+value+")",
+			"value does not match regex pattern "+r.GetPattern())
+	}
+	if r.Prefix != nil {
+		check("!"+g.QualifiedGoIdent(stringsPackage.Ident("HasPrefix"))+"("+value+", "+strconv.Quote(r.GetPrefix())+")",
+			"value does not have prefix "+r.GetPrefix())
+	}
+	if r.Suffix != nil {
+		check("!"+g.QualifiedGoIdent(stringsPackage.Ident("HasSuffix"))+"("+value+", "+strconv.Quote(r.GetSuffix())+")",
+			"value does not have suffix "+r.GetSuffix())
+	}
+	if r.Contains != nil {
+		check("!"+g.QualifiedGoIdent(stringsPackage.Ident("Contains"))+"("+value+", "+strconv.Quote(r.GetContains())+")",
+			"value does not contain substring "+r.GetContains())
+	}
+	if r.NotContains != nil {
+		check(g.QualifiedGoIdent(stringsPackage.Ident("Contains"))+"("+value+", "+strconv.Quote(r.GetNotContains())+")",
+			"value contains substring "+r.GetNotContains())
+	}
+	if len(r.GetIn()) > 0 {
+		genIn(g, path, value, quoteAll(r.GetIn()), true)
+	}
+	if len(r.GetNotIn()) > 0 {
+		genIn(g, path, value, quoteAll(r.GetNotIn()), false)
+	}
+
+	wellKnown := map[string]string{}
+	switch r.GetWellKnown().(type) {
+	case *validate.StringRules_Email:
+		wellKnown["IsEmail"] = "value must be a valid email address"
+	case *validate.StringRules_Hostname:
+		wellKnown["IsHostname"] = "value must be a valid hostname"
+	case *validate.StringRules_Ip:
+		wellKnown["IsIP"] = "value must be a valid IP address"
+	case *validate.StringRules_Ipv4:
+		wellKnown["IsIPv4"] = "value must be a valid IPv4 address"
+	case *validate.StringRules_Ipv6:
+		wellKnown["IsIPv6"] = "value must be a valid IPv6 address"
+	case *validate.StringRules_Uri:
+		wellKnown["IsURI"] = "value must be absolute URI"
+	case *validate.StringRules_UriRef:
+		wellKnown["IsURIRef"] = "value must be a valid URI"
+	case *validate.StringRules_Uuid:
+		wellKnown["IsUUID"] = "value must be a valid UUID"
+	case *validate.StringRules_Address:
+		check("!"+g.QualifiedGoIdent(validatePackage.Ident("IsHostname"))+"("+value+") && !"+
+			g.QualifiedGoIdent(validatePackage.Ident("IsIP"))+"("+value+")", "value must be a valid hostname, or ip address")
+	case nil:
+	default:
+		fmt.Fprintf(os.Stderr, "\u001B[33mWARN\u001B[m: string well known rule %T is not supported, skipped\n", r.GetWellKnown())
+	}
+	for fn, desc := range wellKnown {
+		check("!"+g.QualifiedGoIdent(validatePackage.Ident(fn))+"("+value+")", desc)
+	}
+
+	if r.GetIgnoreEmpty() {
+		g.P("}")
+	}
+}
+
+func genBytesRules(g *protogen.GeneratedFile, r *validate.BytesRules, path, value string) {
+	if r.GetIgnoreEmpty() {
+		g.P("if len(", value, ") > 0 {")
+	}
+
+	check := func(cond, desc string) {
+		g.P("if ", cond, " {")
+		g.P("errs.Add(", path, ", ", strconv.Quote(desc), ")")
+		g.P("}")
+	}
+	bytesFunc := func(name string) string {
+		return g.QualifiedGoIdent(bytesPackage.Ident(name))
+	}
+
+	if r.Const != nil {
+		check("!"+bytesFunc("Equal")+"("+value+", []byte("+strconv.Quote(string(r.GetConst()))+"))", "value must equal "+string(r.GetConst()))
+	}
+	if r.Len != nil {
+		check(fmt.Sprintf("len(%s) != %d", value, r.GetLen()), fmt.Sprintf("value length must be %d bytes", r.GetLen()))
+	}
+	if r.MinLen != nil {
+		check(fmt.Sprintf("len(%s) < %d", value, r.GetMinLen()), fmt.Sprintf("value length must be at least %d bytes", r.GetMinLen()))
+	}
+	if r.MaxLen != nil {
+		check(fmt.Sprintf("len(%s) > %d", value, r.GetMaxLen()), fmt.Sprintf("value length must be at most %d bytes", r.GetMaxLen()))
+	}
+	if r.Pattern != nil {
+		mustCompile(r.GetPattern())
+		check("!"+g.QualifiedGoIdent(validatePackage.Ident("MatchString"))+"("+strconv.Quote(r.GetPattern())+", string("+value+"))",
+			"value does not match regex pattern "+r.GetPattern())
+	}
+	if r.Prefix != nil {
+		check("!"+bytesFunc("HasPrefix")+"("+value+", []byte("+strconv.Quote(string(r.GetPrefix()))+"))", "value does not have prefix "+string(r.GetPrefix()))
+	}
+	if r.Suffix != nil {
+		check("!"+bytesFunc("HasSuffix")+"("+value+", []byte("+strconv.Quote(string(r.GetSuffix()))+"))", "value does not have suffix "+string(r.GetSuffix()))
+	}
+	if r.Contains != nil {
+		check("!"+bytesFunc("Contains")+"("+value+", []byte("+strconv.Quote(string(r.GetContains()))+"))", "value does not contain "+string(r.GetContains()))
+	}
+	if r.WellKnown != nil {
+		fmt.Fprintf(os.Stderr, "\u001B[33mWARN\u001B[m: bytes well known rule %T is not supported, skipped\n", r.GetWellKnown())
+	}
+
+	if r.GetIgnoreEmpty() {
+		g.P("}")
+	}
+}
+
+func genEnumRules(g *protogen.GeneratedFile, field *protogen.Field, r *validate.EnumRules, path, value string) {
+	if r.Const != nil {
+		g.P("if int32(", value, ") != ", r.GetConst(), " {")
+		g.P("errs.Add(", path, `, "value must equal `, r.GetConst(), `")`)
+		g.P("}")
+	}
+	if r.GetDefinedOnly() {
+		names := protogen.GoIdent{
+			GoName:       field.Enum.GoIdent.GoName + "_name",
+			GoImportPath: field.Enum.GoIdent.GoImportPath,
+		}
+		g.P("if _, ok := ", names, "[int32(", value, ")]; !ok {")
+		g.P("errs.Add(", path, `, "value must be one of the defined enum values")`)
+		g.P("}")
+	}
+	if len(r.GetIn()) > 0 {
+		genIn(g, path, "int32("+value+")", formatAll(r.GetIn()), true)
+	}
+	if len(r.GetNotIn()) > 0 {
+		genIn(g, path, "int32("+value+")", formatAll(r.GetNotIn()), false)
+	}
+}
+
+// genNumericRules 生成数值类型的校验代码, 所有数值类型的规则结构相同, 因此通过反射读取
+func genNumericRules(g *protogen.GeneratedFile, r protoreflect.Message, path, value string) {
+	fields := r.Descriptor().Fields()
+	get := func(name string) (protoreflect.Value, bool) {
+		fd := fields.ByName(protoreflect.Name(name))
+		if fd == nil || !r.Has(fd) {
+			return protoreflect.Value{}, false
+		}
+		return r.Get(fd), true
+	}
+	check := func(cond, desc string) {
+		g.P("if ", cond, " {")
+		g.P("errs.Add(", path, ", ", strconv.Quote(desc), ")")
+		g.P("}")
+	}
+
+	ignoreEmpty, _ := get("ignore_empty")
+	if ignoreEmpty.IsValid() && ignoreEmpty.Bool() {
+		g.P("if ", value, " != 0 {")
+	}
+
+	if v, ok := get("const"); ok {
+		check(value+" != "+literal(v), "value must equal "+literal(v))
+	}
+
+	lower, lowerOp, hasLower := numericBound(get, "gt", "gte")
+	upper, upperOp, hasUpper := numericBound(get, "lt", "lte")
+	switch {
+	case hasLower && hasUpper:
+		lowerBracket, upperBracket := "(", ")"
+		if lowerOp == ">=" {
+			lowerBracket = "["
+		}
+		if upperOp == "<=" {
+			upperBracket = "]"
+		}
+		rng := lowerBracket + literal(lower) + ", " + literal(upper) + upperBracket
+		if toFloat(upper) > toFloat(lower) {
+			check(fmt.Sprintf("!(%s %s %s && %s %s %s)", value, lowerOp, literal(lower), value, upperOp, literal(upper)),
+				"value must be inside range "+rng)
+		} else {
+			// 上限小于下限时表示值必须在区间之外
+			check(fmt.Sprintf("!(%s %s %s || %s %s %s)", value, lowerOp, literal(lower), value, upperOp, literal(upper)),
+				"value must be outside range "+rng)
+		}
+	case hasLower:
+		desc := "value must be greater than "
+		if lowerOp == ">=" {
+			desc = "value must be greater than or equal to "
+		}
+		check(fmt.Sprintf("!(%s %s %s)", value, lowerOp, literal(lower)), desc+literal(lower))
+	case hasUpper:
+		desc := "value must be less than "
+		if upperOp == "<=" {
+			desc = "value must be less than or equal to "
+		}
+		check(fmt.Sprintf("!(%s %s %s)", value, upperOp, literal(upper)), desc+literal(upper))
+	}
+
+	for _, name := range [...]string{"in", "not_in"} {
+		fd := fields.ByName(protoreflect.Name(name))
+		list := r.Get(fd).List()
+		if list.Len() == 0 {
+			continue
+		}
+		values := make([]string, 0, list.Len())
+		for i := 0; i < list.Len(); i++ {
+			values = append(values, literal(list.Get(i)))
+		}
+		genIn(g, path, value, values, name == "in")
+	}
+
+	if ignoreEmpty.IsValid() && ignoreEmpty.Bool() {
+		g.P("}")
+	}
+}
+
+func numericBound(get func(string) (protoreflect.Value, bool), exclusive, inclusive string) (protoreflect.Value, string, bool) {
+	op := map[string]string{"gt": ">", "gte": ">=", "lt": "<", "lte": "<="}
+	if v, ok := get(exclusive); ok {
+		return v, op[exclusive], true
+	}
+	if v, ok := get(inclusive); ok {
+		return v, op[inclusive], true
+	}
+
+	return protoreflect.Value{}, "", false
+}
+
+// genIn 生成 in 和 not_in 的校验代码
+func genIn(g *protogen.GeneratedFile, path, value string, values []string, in bool) {
+	g.P("switch ", value, " {")
+	g.P("case ", strings.Join(values, ", "), ":")
+	if in {
+		g.P("default:")
+		g.P("errs.Add(", path, ", ", strconv.Quote("value must be in list ["+strings.Join(values, ", ")+"]"), ")")
+	} else {
+		g.P("errs.Add(", path, ", ", strconv.Quote("value must not be in list ["+strings.Join(values, ", ")+"]"), ")")
+	}
+	g.P("}")
+}
+
+func literal(v protoreflect.Value) string {
+	return fmt.Sprint(v.Interface())
+}
+
+func toFloat(v protoreflect.Value) float64 {
+	switch x := v.Interface().(type) {
+	case int32:
+		return float64(x)
+	case int64:
+		return float64(x)
+	case uint32:
+		return float64(x)
+	case uint64:
+		return float64(x)
+	case float32:
+		return float64(x)
+	case float64:
+		return x
+	}
+
+	return 0
+}
+
+func quoteAll(values []string) []string {
+	quoted := make([]string, 0, len(values))
+	for _, v := range values {
+		quoted = append(quoted, strconv.Quote(v))
+	}
+
+	return quoted
+}
+
+func formatAll(values []int32) []string {
+	formatted := make([]string, 0, len(values))
+	for _, v := range values {
+		formatted = append(formatted, strconv.FormatInt(int64(v), 10))
+	}
+
+	return formatted
+}
+
+func mustCompile(pattern string) {
+	if _, err := regexp.Compile(pattern); err != nil {
+		fmt.Fprintf(os.Stderr, "\u001B[31mERROR\u001B[m: invalid regex pattern %s: %v\n", pattern, err)
+		os.Exit(1)
+	}
+}
+
+// goScalarType 返回标量类型在Go中对应的类型
+func goScalarType(kind protoreflect.Kind) string {
+	switch kind {
+	case protoreflect.BoolKind:
+		return "bool"
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return "int32"
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return "int64"
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return "uint32"
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return "uint64"
+	case protoreflect.FloatKind:
+		return "float32"
+	case protoreflect.DoubleKind:
+		return "float64"
+	case protoreflect.StringKind:
+		return "string"
+	case protoreflect.BytesKind:
+		return "[]byte"
+	}
+
+	return "any"
+}
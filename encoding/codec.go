@@ -0,0 +1,84 @@
+package encoding
+
+import (
+	"mime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Codec 编解码器, 按照内容类型的子类型注册, 如 application/json 对应 json
+type Codec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+	// Name 内容类型的子类型, 如 json
+	Name() string
+}
+
+var (
+	mu               sync.RWMutex
+	registeredCodecs = make(map[string]Codec)
+)
+
+// RegisterCodec 注册编解码器, 同名的编解码器会被覆盖
+func RegisterCodec(codec Codec) {
+	if codec == nil {
+		panic("cannot register a nil Codec")
+	}
+	if codec.Name() == "" {
+		panic("cannot register Codec with empty string result for Name()")
+	}
+
+	mu.Lock()
+	registeredCodecs[strings.ToLower(codec.Name())] = codec
+	mu.Unlock()
+}
+
+// GetCodec 根据名称获取编解码器, 不存在时返回nil
+func GetCodec(name string) Codec {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	return registeredCodecs[strings.ToLower(name)]
+}
+
+// GetCodecByContentType 根据内容类型获取编解码器
+// 如 application/json; charset=utf-8 对应 json, application/problem+json 也对应 json
+func GetCodecByContentType(contentType string) Codec {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil
+	}
+
+	_, subtype, ok := strings.Cut(mediaType, "/")
+	if !ok {
+		return nil
+	}
+	if codec := GetCodec(subtype); codec != nil {
+		return codec
+	}
+	if i := strings.LastIndexByte(subtype, '+'); i != -1 {
+		return GetCodec(subtype[i+1:])
+	}
+
+	return nil
+}
+
+// ContentType 返回编解码器对应的内容类型
+func ContentType(name string) string {
+	return "application/" + name
+}
+
+// Names 返回所有已注册的编解码器名称, 按照名称排序
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	names := make([]string, 0, len(registeredCodecs))
+	for name := range registeredCodecs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
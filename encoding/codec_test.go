@@ -0,0 +1,93 @@
+package encoding
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+type testCodec struct {
+	name string
+}
+
+func (c testCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (c testCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+func (c testCodec) Name() string {
+	return c.name
+}
+
+func TestGetCodecByContentType(t *testing.T) {
+	RegisterCodec(testCodec{name: "test"})
+	RegisterCodec(testCodec{name: "Test-Upper"})
+
+	tests := []struct {
+		contentType string
+		want        string
+	}{
+		{"application/test", "test"},
+		{"application/test; charset=utf-8", "test"},
+		{"application/problem+test", "test"},
+		{"application/TEST-UPPER", "Test-Upper"},
+		{"application/xml", ""},
+		{"application/problem+xml", ""},
+		{"test", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		codec := GetCodecByContentType(tt.contentType)
+		if (codec == nil && tt.want != "") || (codec != nil && codec.Name() != tt.want) {
+			t.Errorf("GetCodecByContentType(%q) = %v, want %q", tt.contentType, codec, tt.want)
+		}
+	}
+
+	names := Names()
+	if !reflect.DeepEqual(names, []string{"test", "test-upper"}) {
+		t.Errorf("Names() = %v", names)
+	}
+	if ContentType("test") != "application/test" {
+		t.Errorf("ContentType(test) = %s", ContentType("test"))
+	}
+}
+
+func TestCodecRoundTrip(t *testing.T) {
+	RegisterCodec(testCodec{name: "test"})
+
+	type user struct {
+		Id   int64             `json:"id"`
+		Name string            `json:"name"`
+		Tags map[string]string `json:"tags"`
+	}
+	in := user{Id: 1 << 60, Name: "tom", Tags: map[string]string{"a": "b"}}
+
+	codec := GetCodecByContentType(ContentType("test"))
+	data, err := codec.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := user{}
+	if err := codec.Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(in, out) {
+		t.Errorf("round trip = %+v, want %+v", out, in)
+	}
+}
+
+func TestRegisterCodecPanics(t *testing.T) {
+	for _, codec := range []Codec{nil, testCodec{}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("RegisterCodec(%v) did not panic", codec)
+				}
+			}()
+			RegisterCodec(codec)
+		}()
+	}
+}
@@ -0,0 +1,50 @@
+package json
+
+import (
+	"encoding/json"
+
+	"github.com/mangohow/gowlb/encoding"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// Name json编解码器名称
+const Name = "json"
+
+var (
+	// MarshalOptions proto.Message 序列化选项
+	MarshalOptions = protojson.MarshalOptions{
+		UseProtoNames: true,
+	}
+	// UnmarshalOptions proto.Message 反序列化选项
+	UnmarshalOptions = protojson.UnmarshalOptions{
+		DiscardUnknown: true,
+	}
+)
+
+func init() {
+	encoding.RegisterCodec(codec{})
+}
+
+// codec proto.Message 使用protojson编解码, 其它类型使用encoding/json
+type codec struct{}
+
+func (codec) Marshal(v any) ([]byte, error) {
+	if m, ok := v.(proto.Message); ok {
+		return MarshalOptions.Marshal(m)
+	}
+
+	return json.Marshal(v)
+}
+
+func (codec) Unmarshal(data []byte, v any) error {
+	if m, ok := v.(proto.Message); ok {
+		return UnmarshalOptions.Unmarshal(data, m)
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+func (codec) Name() string {
+	return Name
+}
@@ -0,0 +1,139 @@
+package json
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mangohow/gowlb/encoding"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/typepb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// jsonEqual 比较两个json是否等价, protojson的输出中会随机插入空格
+func jsonEqual(t *testing.T, got []byte, want string) bool {
+	var g, w any
+	if err := json.Unmarshal(got, &g); err != nil {
+		t.Fatalf("invalid json %s: %v", got, err)
+	}
+	if err := json.Unmarshal([]byte(want), &w); err != nil {
+		t.Fatalf("invalid json %s: %v", want, err)
+	}
+
+	return reflect.DeepEqual(g, w)
+}
+
+func TestCodecProtoRoundTrip(t *testing.T) {
+	codec := encoding.GetCodec(Name)
+	tests := []struct {
+		name string
+		in   proto.Message
+		want string
+	}{
+		{
+			name: "enum and proto names",
+			in: &typepb.Field{
+				Kind:        typepb.Field_TYPE_INT64,
+				Cardinality: typepb.Field_CARDINALITY_REPEATED,
+				Number:      1,
+				Name:        "user_id",
+				JsonName:    "userId",
+			},
+			want: `{"kind":"TYPE_INT64","cardinality":"CARDINALITY_REPEATED","number":1,"name":"user_id","json_name":"userId"}`,
+		},
+		{
+			name: "int64 as string",
+			in:   &descriptorpb.UninterpretedOption{NegativeIntValue: proto.Int64(math.MinInt64), PositiveIntValue: proto.Uint64(math.MaxUint64)},
+			want: `{"negative_int_value":"-9223372036854775808","positive_int_value":"18446744073709551615"}`,
+		},
+		{
+			name: "wrapper",
+			in:   wrapperspb.Int64(math.MaxInt64),
+			want: `"9223372036854775807"`,
+		},
+		{
+			name: "timestamp",
+			in:   timestamppb.New(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)),
+			want: `"2024-01-02T03:04:05Z"`,
+		},
+		{
+			name: "nested duration",
+			in:   &errdetails.RetryInfo{RetryDelay: durationpb.New(1500 * time.Millisecond)},
+			want: `{"retry_delay":"1.500s"}`,
+		},
+		{
+			name: "struct",
+			in: &structpb.Struct{Fields: map[string]*structpb.Value{
+				"name": structpb.NewStringValue("tom"),
+				"tags": structpb.NewListValue(&structpb.ListValue{Values: []*structpb.Value{structpb.NewNumberValue(1)}}),
+			}},
+			want: `{"name":"tom","tags":[1]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		data, err := codec.Marshal(tt.in)
+		if err != nil {
+			t.Errorf("%s: Marshal() error = %v", tt.name, err)
+			continue
+		}
+		if !jsonEqual(t, data, tt.want) {
+			t.Errorf("%s: Marshal() = %s, want %s", tt.name, data, tt.want)
+		}
+
+		out := tt.in.ProtoReflect().New().Interface()
+		if err := codec.Unmarshal(data, out); err != nil {
+			t.Errorf("%s: Unmarshal() error = %v", tt.name, err)
+			continue
+		}
+		if !proto.Equal(tt.in, out) {
+			t.Errorf("%s: round trip = %v, want %v", tt.name, out, tt.in)
+		}
+	}
+}
+
+func TestCodecProtoUnmarshal(t *testing.T) {
+	// 反序列化时接受枚举值的数字、json名称, 并忽略未知字段
+	out := &typepb.Field{}
+	err := encoding.GetCodec(Name).Unmarshal([]byte(`{"kind":3,"jsonName":"userId","unknown":true}`), out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Kind != typepb.Field_TYPE_INT64 || out.JsonName != "userId" {
+		t.Errorf("Unmarshal() = %v", out)
+	}
+}
+
+func TestCodecStructRoundTrip(t *testing.T) {
+	type user struct {
+		Id      int64     `json:"id"`
+		Name    string    `json:"name,omitempty"`
+		Created time.Time `json:"created"`
+	}
+	codec := encoding.GetCodecByContentType("application/json; charset=utf-8")
+	in := user{Id: 1 << 60, Created: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}
+
+	data, err := codec.Marshal(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"id":1152921504606846976,"created":"2024-01-02T03:04:05Z"}`; string(data) != want {
+		t.Errorf("Marshal() = %s, want %s", data, want)
+	}
+
+	out := user{}
+	if err := codec.Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(in, out) {
+		t.Errorf("round trip = %+v, want %+v", out, in)
+	}
+}
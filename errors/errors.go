@@ -1,6 +1,7 @@
 package errors
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 )
@@ -65,6 +66,16 @@ func (e *ErrorImpl) Unwrap() error {
 	return e.cause
 }
 
+// Is 实现errors.Is, code和reason都相同时认为是同一个错误, 不比较message和metadata
+func (e *ErrorImpl) Is(target error) bool {
+	t, ok := target.(Error)
+	if !ok {
+		return false
+	}
+
+	return t.Code() == e.Code_ && t.Reason() == e.Reason_
+}
+
 func New(code, status int32, reason, message string) Error {
 	return &ErrorImpl{
 		status:   status,
@@ -92,6 +103,45 @@ func FromErrorf(code, status int32, reason string, err error, format string, arg
 	return FromError(code, status, reason, fmt.Sprintf(format, args...), err)
 }
 
+// WithMetadata 返回携带metadata的新错误, 原错误不会被修改
+func WithMetadata(err Error, md map[string]string) Error {
+	return &ErrorImpl{
+		cause:     err.Unwrap(),
+		status:    err.HttpStatus(),
+		Code_:     err.Code(),
+		Reason_:   err.Reason(),
+		Message_:  err.Message(),
+		Metadata_: md,
+	}
+}
+
+// WithStatus 返回使用指定http状态码的新错误, 原错误不会被修改
+func WithStatus(err Error, status int32) Error {
+	return &ErrorImpl{
+		cause:     err.Unwrap(),
+		status:    status,
+		Code_:     err.Code(),
+		Reason_:   err.Reason(),
+		Message_:  err.Message(),
+		Metadata_: err.Metadata(),
+	}
+}
+
+// Is 与标准库errors.Is相同
+func Is(err, target error) bool {
+	return errors.Is(err, target)
+}
+
+// AsError 在错误链中查找errors.Error
+func AsError(err error) (Error, bool) {
+	var e Error
+	if errors.As(err, &e) {
+		return e, true
+	}
+
+	return nil, false
+}
+
 func IsError(err error) bool {
 	_, ok := err.(Error)
 
@@ -34,6 +34,14 @@ func NotFoundCause(code int32, reason, message string, err error) Error {
 	return FromError(code, http.StatusNotFound, reason, message, err)
 }
 
+func MethodNotAllowed(code int32, reason, message string) Error {
+	return New(code, http.StatusMethodNotAllowed, reason, message)
+}
+
+func MethodNotAllowedCause(code int32, reason, message string, err error) Error {
+	return FromError(code, http.StatusMethodNotAllowed, reason, message, err)
+}
+
 func InternalServer(code int32, reason, message string) Error {
 	return New(code, http.StatusInternalServerError, reason, message)
 }
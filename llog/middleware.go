@@ -25,13 +25,25 @@ func FromContext(ctx context.Context) *zap.SugaredLogger {
 	if logger, ok := ctx.Value(loggerKey{}).(*zap.SugaredLogger); ok {
 		return logger
 	}
+	return defaultLogger()
+}
+
+// defaultLogger 返回全局 logger, 没有调用 NewLogger 初始化时使用 zap 的全局 logger
+func defaultLogger() *zap.SugaredLogger {
+	if log == nil {
+		return zap.S()
+	}
 	return log
 }
 
 // LoggerInjectMiddleware 中间件：注入带 RequestID 的 logger
+// 非http请求(如grpc)直接调用handler, grpc服务在调用中间件之前已经注入了带 RequestID 的 logger
 func LoggerInjectMiddleware(requestIdKey string) http.Middleware {
 	return func(ctx context.Context, req interface{}, handler http.Handler) (interface{}, error) {
 		c := http.FromContext(ctx)
+		if c == nil {
+			return handler(ctx, req)
+		}
 		// 1. 获取/生成 RequestID
 		if requestIdKey == "" {
 			requestIdKey = requestIdKeyName
@@ -43,7 +55,7 @@ func LoggerInjectMiddleware(requestIdKey string) http.Middleware {
 		c.ResponseWriter().Header().Set("X-Request-ID", rid) // 响应头返回
 
 		// 2. 创建带 RequestID 的 logger
-		requestLogger := log.With("requestId", rid)
+		requestLogger := defaultLogger().With("requestId", rid)
 
 		// 3. 注入 logger 到 context
 		cc := WithLogger(ctx, requestLogger)
@@ -53,41 +65,37 @@ func LoggerInjectMiddleware(requestIdKey string) http.Middleware {
 	}
 }
 
+// RequestLoggingMiddleware 中间件：记录请求日志
+// 非http请求(如grpc)没有请求路径等信息, 使用 http.OperationFromContext 获取的方法全名代替
 func RequestLoggingMiddleware() http.Middleware {
 	return func(ctx context.Context, req interface{}, handler http.Handler) (interface{}, error) {
-		var (
-			logger  = FromContext(ctx)
-			c       = http.FromContext(ctx)
-			request = c.Request()
-		)
+		logger := FromContext(ctx)
+
+		// 1. 构建请求相关的日志字段
+		var fields []interface{}
+		if c := http.FromContext(ctx); c != nil {
+			request := c.Request()
+			clientIP := request.Header.Get("X-Real-IP")
+			if clientIP == "" {
+				clientIP = request.Header.Get("X-Forwarded-For")
+			}
+			if clientIP == "" {
+				clientIP = request.RemoteAddr
+			}
+			fields = append(fields,
+				"method", request.Method,
+				"path", request.URL.Path,
+				"query", request.URL.RawQuery,
+				"ip", clientIP,
+			)
+		} else if op, ok := http.OperationFromContext(ctx); ok {
+			fields = append(fields, "operation", op.FullName())
+		}
 
-		// 1. 开始计时
+		// 2. 处理请求并计算延迟
 		start := time.Now()
-		path := request.URL.Path
-		raw := request.URL.RawQuery
-
-		// 2. 处理请求
 		resp, err := handler(ctx, req)
-
-		// 3. 计算延迟
-		latency := time.Since(start)
-		clientIP := request.Header.Get("X-Real-IP")
-		if clientIP == "" {
-			clientIP = request.Header.Get("X-Forwarded-For")
-		}
-		if clientIP == "" {
-			clientIP = request.RemoteAddr
-		}
-		method := request.Method
-
-		// 4. 构建日志字段
-		fields := []interface{}{
-			"method", method,
-			"path", path,
-			"query", raw,
-			"ip", clientIP,
-			"latency", latency,
-		}
+		fields = append(fields, "latency", time.Since(start))
 
 		if err != nil {
 			if e, ok := err.(errors.Error); ok {
@@ -99,7 +107,7 @@ func RequestLoggingMiddleware() http.Middleware {
 			}
 		}
 
-		// 5. 按状态码决定日志级别
+		// 3. 按状态码决定日志级别
 		if err != nil {
 			logger.Errorw("Server error", fields...)
 		} else {
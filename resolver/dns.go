@@ -0,0 +1,39 @@
+package resolver
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultDNSInterval = 30 * time.Second
+
+var lookupSRV = net.DefaultResolver.LookupSRV
+
+// DNSSRV 通过DNS SRV记录获取地址列表, 每隔interval重新查询一次, interval为0时使用默认的30s
+// service和proto为空时直接查询name, 例如 DNSSRV("http", "tcp", "user.svc.cluster.local", 0)
+// 查询的是 _http._tcp.user.svc.cluster.local
+func DNSSRV(service, proto, name string, interval time.Duration) Resolver {
+	if interval <= 0 {
+		interval = defaultDNSInterval
+	}
+
+	return resolverFunc(func(ctx context.Context) (Watcher, error) {
+		return newPollWatcher(ctx, interval, func(ctx context.Context) ([]string, error) {
+			_, records, err := lookupSRV(ctx, service, proto, name)
+			if err != nil {
+				return nil, err
+			}
+
+			addrs := make([]string, 0, len(records))
+			for _, record := range records {
+				host := strings.TrimSuffix(record.Target, ".")
+				addrs = append(addrs, net.JoinHostPort(host, strconv.Itoa(int(record.Port))))
+			}
+
+			return addrs, nil
+		}), nil
+	})
+}
@@ -0,0 +1,45 @@
+package resolver
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"time"
+)
+
+const defaultFileInterval = 5 * time.Second
+
+// File 从文件中读取地址列表, 每行一个地址, 忽略空行和以#开头的注释
+// 每隔interval检查一次文件内容, interval为0时使用默认的5s
+func File(path string, interval time.Duration) Resolver {
+	if interval <= 0 {
+		interval = defaultFileInterval
+	}
+
+	return resolverFunc(func(ctx context.Context) (Watcher, error) {
+		if _, err := os.Stat(path); err != nil {
+			return nil, err
+		}
+
+		return newPollWatcher(ctx, interval, func(context.Context) ([]string, error) {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil, err
+			}
+
+			var addrs []string
+			scanner := bufio.NewScanner(bytes.NewReader(data))
+			for scanner.Scan() {
+				line := strings.TrimSpace(scanner.Text())
+				if line == "" || strings.HasPrefix(line, "#") {
+					continue
+				}
+				addrs = append(addrs, line)
+			}
+
+			return addrs, scanner.Err()
+		}), nil
+	})
+}
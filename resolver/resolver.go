@@ -0,0 +1,138 @@
+package resolver
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// Resolver 提供服务实例的地址列表, 地址为 host:port 或 scheme://host:port
+type Resolver interface {
+	// Watch 监听地址列表的变化, ctx结束或者调用Watcher.Stop后停止监听
+	Watch(ctx context.Context) (Watcher, error)
+}
+
+// Watcher 地址列表监听器
+type Watcher interface {
+	// Next 第一次调用时返回当前的地址列表, 之后阻塞直到地址列表发生变化
+	// 获取地址列表失败时返回错误, 调用方可以继续调用Next
+	Next() ([]string, error)
+	// Stop 停止监听, 阻塞中的Next返回context.Canceled
+	Stop() error
+}
+
+// Static 固定的地址列表
+func Static(addrs ...string) Resolver {
+	return resolverFunc(func(ctx context.Context) (Watcher, error) {
+		return newPollWatcher(ctx, 0, func(context.Context) ([]string, error) {
+			return addrs, nil
+		}), nil
+	})
+}
+
+type resolverFunc func(ctx context.Context) (Watcher, error)
+
+func (f resolverFunc) Watch(ctx context.Context) (Watcher, error) {
+	return f(ctx)
+}
+
+// pollWatcher 每隔interval调用一次fetch, 地址列表发生变化时Next返回
+// interval为0时只获取一次地址列表
+type pollWatcher struct {
+	ctx      context.Context
+	cancel   context.CancelFunc
+	interval time.Duration
+	fetch    func(ctx context.Context) ([]string, error)
+
+	last []string
+	// 上一次获取失败, 下一次成功时即使地址列表没有变化也需要返回
+	failed bool
+	first  bool
+}
+
+func newPollWatcher(ctx context.Context, interval time.Duration, fetch func(ctx context.Context) ([]string, error)) *pollWatcher {
+	ctx, cancel := context.WithCancel(ctx)
+	return &pollWatcher{
+		ctx:      ctx,
+		cancel:   cancel,
+		interval: interval,
+		fetch:    fetch,
+		first:    true,
+	}
+}
+
+func (w *pollWatcher) Next() ([]string, error) {
+	for {
+		if !w.first {
+			if err := w.wait(); err != nil {
+				return nil, err
+			}
+		}
+		w.first = false
+
+		addrs, err := w.fetch(w.ctx)
+		if err != nil {
+			if w.ctx.Err() != nil {
+				return nil, w.ctx.Err()
+			}
+			w.failed = true
+			return nil, err
+		}
+
+		addrs = normalize(addrs)
+		if w.last == nil || w.failed || !equal(addrs, w.last) {
+			w.last, w.failed = addrs, false
+			return addrs, nil
+		}
+	}
+}
+
+func (w *pollWatcher) wait() error {
+	if w.interval <= 0 {
+		<-w.ctx.Done()
+		return w.ctx.Err()
+	}
+
+	timer := time.NewTimer(w.interval)
+	defer timer.Stop()
+	select {
+	case <-w.ctx.Done():
+		return w.ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func (w *pollWatcher) Stop() error {
+	w.cancel()
+	return nil
+}
+
+// normalize 去重并排序, 便于比较地址列表是否发生变化
+func normalize(addrs []string) []string {
+	seen := make(map[string]struct{}, len(addrs))
+	result := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		if _, ok := seen[addr]; ok || addr == "" {
+			continue
+		}
+		seen[addr] = struct{}{}
+		result = append(result, addr)
+	}
+	sort.Strings(result)
+
+	return result
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
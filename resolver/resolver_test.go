@@ -0,0 +1,91 @@
+package resolver
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStatic(t *testing.T) {
+	w, err := Static("b:80", "a:80", "b:80").Watch(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addrs, err := w.Next()
+	if err != nil || len(addrs) != 2 || addrs[0] != "a:80" || addrs[1] != "b:80" {
+		t.Fatalf("next = %v, %v", addrs, err)
+	}
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		w.Stop()
+	}()
+	if _, err = w.Next(); err != context.Canceled {
+		t.Fatalf("next after stop = %v, want context.Canceled", err)
+	}
+}
+
+func TestFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "endpoints")
+	if err := os.WriteFile(path, []byte("# replicas\n10.0.0.1:8080\n\n10.0.0.2:8080\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := File(path, 10*time.Millisecond).Watch(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+
+	addrs, err := w.Next()
+	if err != nil || len(addrs) != 2 {
+		t.Fatalf("next = %v, %v", addrs, err)
+	}
+
+	if err := os.WriteFile(path, []byte("https://10.0.0.3:8443\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	addrs, err = w.Next()
+	if err != nil || len(addrs) != 1 || addrs[0] != "https://10.0.0.3:8443" {
+		t.Fatalf("next = %v, %v", addrs, err)
+	}
+
+	if _, err := File(filepath.Join(t.TempDir(), "missing"), 0).Watch(context.Background()); err == nil {
+		t.Fatal("watch missing file should fail")
+	}
+}
+
+func TestDNSSRV(t *testing.T) {
+	defer func(fn func(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)) {
+		lookupSRV = fn
+	}(lookupSRV)
+
+	records := []*net.SRV{{Target: "a.user.svc.", Port: 8080}}
+	lookupSRV = func(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+		if service != "http" || proto != "tcp" || name != "user.svc" {
+			t.Errorf("lookup %s %s %s", service, proto, name)
+		}
+		return "", records, nil
+	}
+
+	w, err := DNSSRV("http", "tcp", "user.svc", 10*time.Millisecond).Watch(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+
+	addrs, err := w.Next()
+	if err != nil || len(addrs) != 1 || addrs[0] != "a.user.svc:8080" {
+		t.Fatalf("next = %v, %v", addrs, err)
+	}
+
+	records = append([]*net.SRV{{Target: "b.user.svc.", Port: 8080}}, records...)
+	addrs, err = w.Next()
+	if err != nil || len(addrs) != 2 {
+		t.Fatalf("next = %v, %v", addrs, err)
+	}
+}
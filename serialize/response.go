@@ -1,8 +1,73 @@
 package serialize
 
-import "github.com/mangohow/gowlb/errors"
+import (
+	"encoding/json"
+
+	"github.com/mangohow/gowlb/encoding"
+	_ "github.com/mangohow/gowlb/encoding/json"
+	"github.com/mangohow/gowlb/errors"
+)
 
 type Response struct {
 	Data  interface{}  `json:"data"`
 	Error errors.Error `json:"error"`
 }
+
+type rawResponse struct {
+	Data  json.RawMessage `json:"data"`
+	Error json.RawMessage `json:"error"`
+}
+
+// MarshalJSON Data使用注册的json编解码器序列化, 从而正确处理proto.Message
+func (r Response) MarshalJSON() ([]byte, error) {
+	raw := rawResponse{
+		Data:  json.RawMessage("null"),
+		Error: json.RawMessage("null"),
+	}
+
+	var err error
+	if r.Data != nil {
+		if raw.Data, err = encoding.GetCodec("json").Marshal(r.Data); err != nil {
+			return nil, err
+		}
+	}
+	if r.Error != nil {
+		if raw.Error, err = json.Marshal(r.Error); err != nil {
+			return nil, err
+		}
+	}
+
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON 如果Data不为nil, 则将数据反序列化到Data中
+func (r *Response) UnmarshalJSON(data []byte) error {
+	var raw rawResponse
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	if !isNull(raw.Data) {
+		if r.Data != nil {
+			if err := encoding.GetCodec("json").Unmarshal(raw.Data, r.Data); err != nil {
+				return err
+			}
+		} else if err := json.Unmarshal(raw.Data, &r.Data); err != nil {
+			return err
+		}
+	}
+
+	if !isNull(raw.Error) {
+		e := &errors.ErrorImpl{}
+		if err := json.Unmarshal(raw.Error, e); err != nil {
+			return err
+		}
+		r.Error = e
+	}
+
+	return nil
+}
+
+func isNull(data json.RawMessage) bool {
+	return len(data) == 0 || string(data) == "null"
+}
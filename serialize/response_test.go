@@ -0,0 +1,97 @@
+package serialize
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/mangohow/gowlb/errors"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/typepb"
+)
+
+func TestResponseRoundTrip(t *testing.T) {
+	type user struct {
+		Id   int64  `json:"id"`
+		Name string `json:"name"`
+	}
+	notFound := errors.WithMetadata(errors.NotFound(404001, "UserNotFound", "user not found"), map[string]string{"id": "1"})
+
+	tests := []struct {
+		name string
+		in   Response
+		// 反序列化时Data的类型
+		data any
+		want Response
+	}{
+		{
+			name: "proto data",
+			in:   Response{Data: &typepb.Field{Kind: typepb.Field_TYPE_INT64, Name: "user_id"}},
+			data: &typepb.Field{},
+			want: Response{Data: &typepb.Field{Kind: typepb.Field_TYPE_INT64, Name: "user_id"}},
+		},
+		{
+			name: "struct data",
+			in:   Response{Data: user{Id: 1, Name: "tom"}},
+			data: &user{},
+			want: Response{Data: &user{Id: 1, Name: "tom"}},
+		},
+		{
+			name: "untyped data",
+			in:   Response{Data: user{Id: 1, Name: "tom"}},
+			want: Response{Data: map[string]any{"id": float64(1), "name": "tom"}},
+		},
+		{
+			name: "nil data",
+			in:   Response{},
+			data: &user{},
+			want: Response{Data: &user{}},
+		},
+		{
+			name: "error",
+			in:   Response{Error: notFound},
+			want: Response{Error: notFound},
+		},
+	}
+
+	for _, tt := range tests {
+		data, err := json.Marshal(tt.in)
+		if err != nil {
+			t.Errorf("%s: Marshal() error = %v", tt.name, err)
+			continue
+		}
+
+		out := Response{Data: tt.data}
+		if err := json.Unmarshal(data, &out); err != nil {
+			t.Errorf("%s: Unmarshal(%s) error = %v", tt.name, data, err)
+			continue
+		}
+
+		if m, ok := tt.want.Data.(proto.Message); ok {
+			if !proto.Equal(m, out.Data.(proto.Message)) {
+				t.Errorf("%s: data = %v, want %v", tt.name, out.Data, m)
+			}
+		} else if !reflect.DeepEqual(out.Data, tt.want.Data) {
+			t.Errorf("%s: data = %#v, want %#v", tt.name, out.Data, tt.want.Data)
+		}
+
+		if (out.Error == nil) != (tt.want.Error == nil) {
+			t.Errorf("%s: error = %v, want %v", tt.name, out.Error, tt.want.Error)
+			continue
+		}
+		if e := tt.want.Error; e != nil && (out.Error.Code() != e.Code() || out.Error.Reason() != e.Reason() ||
+			out.Error.Message() != e.Message() || !reflect.DeepEqual(out.Error.Metadata(), e.Metadata())) {
+			t.Errorf("%s: error = %v, want %v", tt.name, out.Error, e)
+		}
+	}
+}
+
+func TestResponseMarshalNull(t *testing.T) {
+	data, err := json.Marshal(Response{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"data":null,"error":null}` {
+		t.Errorf("Marshal() = %s", data)
+	}
+}
@@ -0,0 +1,109 @@
+package binding
+
+import (
+	"bytes"
+	"errors"
+	"mime/multipart"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestPathVarBinding(t *testing.T) {
+	type request struct {
+		Id    int64   `param:"id"`
+		Name  *string `json:"name,omitempty"`
+		Tags  []int   `param:"tags"`
+		Extra string  `param:"-"`
+	}
+
+	t.Run("bind vars", func(t *testing.T) {
+		r := httptest.NewRequest("GET", "/user/12/tom", nil)
+		r = mux.SetURLVars(r, map[string]string{"id": "12", "name": "tom", "tags": "3"})
+
+		var req request
+		if err := (PathVarBinding{Tag: "param"}).Bind(r, &req); err != nil {
+			t.Fatalf("Bind() error = %v", err)
+		}
+		if req.Id != 12 || req.Name == nil || *req.Name != "tom" || len(req.Tags) != 1 || req.Tags[0] != 3 {
+			t.Errorf("Bind() = %+v", req)
+		}
+	})
+
+	t.Run("unknown var", func(t *testing.T) {
+		r := mux.SetURLVars(httptest.NewRequest("GET", "/", nil), map[string]string{"extra": "x"})
+
+		var e *UnknownVarError
+		if err := (PathVarBinding{}).Bind(r, &request{}); !errors.As(err, &e) || e.Name != "extra" {
+			t.Errorf("Bind() error = %v, want UnknownVarError", err)
+		}
+	})
+
+	t.Run("invalid value", func(t *testing.T) {
+		r := mux.SetURLVars(httptest.NewRequest("GET", "/", nil), map[string]string{"id": "abc"})
+
+		var e *ParseError
+		if err := (PathVarBinding{}).Bind(r, &request{}); !errors.As(err, &e) || e.Field != "Id" {
+			t.Errorf("Bind() error = %v, want ParseError", err)
+		}
+	})
+}
+
+func TestFormBinding(t *testing.T) {
+	type request struct {
+		Name   string                  `form:"name"`
+		Age    *int                    `form:"age"`
+		Ids    []uint                  `form:"ids"`
+		Avatar *multipart.FileHeader   `form:"avatar"`
+		Files  []*multipart.FileHeader `form:"files"`
+	}
+
+	t.Run("urlencoded", func(t *testing.T) {
+		form := url.Values{"name": {"tom"}, "age": {"18"}, "ids": {"1", "2"}}
+		r := httptest.NewRequest("POST", "/", strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+
+		var req request
+		if err := (FormBinding{}).Bind(r, &req); err != nil {
+			t.Fatalf("Bind() error = %v", err)
+		}
+		if req.Name != "tom" || req.Age == nil || *req.Age != 18 || len(req.Ids) != 2 || req.Ids[1] != 2 {
+			t.Errorf("Bind() = %+v", req)
+		}
+	})
+
+	t.Run("multipart", func(t *testing.T) {
+		body := &bytes.Buffer{}
+		mw := multipart.NewWriter(body)
+		_ = mw.WriteField("name", "tom")
+		for _, name := range []string{"avatar", "files", "files"} {
+			fw, _ := mw.CreateFormFile(name, name+".txt")
+			_, _ = fw.Write([]byte("content"))
+		}
+		_ = mw.Close()
+
+		r := httptest.NewRequest("POST", "/", body)
+		r.Header.Set("Content-Type", mw.FormDataContentType())
+
+		var req request
+		if err := (FormBinding{MaxMemory: 1024}).Bind(r, &req); err != nil {
+			t.Fatalf("Bind() error = %v", err)
+		}
+		if req.Name != "tom" || req.Avatar == nil || req.Avatar.Filename != "avatar.txt" || len(req.Files) != 2 {
+			t.Errorf("Bind() = %+v", req)
+		}
+	})
+
+	t.Run("invalid value", func(t *testing.T) {
+		r := httptest.NewRequest("POST", "/", strings.NewReader("age=abc"))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		var e *ParseError
+		if err := (FormBinding{}).Bind(r, &request{}); !errors.As(err, &e) || e.Key != "age" {
+			t.Errorf("Bind() error = %v, want ParseError", err)
+		}
+	})
+}
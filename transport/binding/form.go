@@ -1,10 +1,96 @@
 package binding
 
-import "net/http"
+import (
+	"errors"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"reflect"
+)
 
-type FormBinding struct{}
+const defaultMultipartMemory = 32 << 20 // 32 MB
+
+var (
+	fileHeaderType      = reflect.TypeOf((*multipart.FileHeader)(nil))
+	fileHeaderSliceType = reflect.TypeOf([]*multipart.FileHeader(nil))
+)
+
+// FormBinding 绑定 x-www-form-urlencoded 和 multipart/form-data 表单参数
+// 上传的文件可以绑定到 *multipart.FileHeader 或 []*multipart.FileHeader 类型的字段
+type FormBinding struct {
+	// 结构体标签, 默认为form
+	Tag string
+	// 解析multipart表单时使用的最大内存, 超出部分写入临时文件, 默认为32MB
+	MaxMemory int64
+}
 
 func (f FormBinding) Bind(r *http.Request, obj any) error {
+	if r == nil {
+		return errors.New("bind form failed: invalid request")
+	}
+
+	tag := f.Tag
+	if tag == "" {
+		tag = "form"
+	}
+
+	var files map[string][]*multipart.FileHeader
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		maxMemory := f.MaxMemory
+		if maxMemory <= 0 {
+			maxMemory = defaultMultipartMemory
+		}
+		if err := r.ParseMultipartForm(maxMemory); err != nil {
+			return errors.New("bind form failed: " + err.Error())
+		}
+		files = r.MultipartForm.File
+	} else if err := r.ParseForm(); err != nil {
+		return errors.New("bind form failed: " + err.Error())
+	}
+
+	elemValue, err := structElem("form", obj)
+	if err != nil {
+		return err
+	}
+	elemType := elemValue.Type()
+
+	for i := 0; i < elemType.NumField(); i++ {
+		field := elemType.Field(i)
+		fieldValue := elemValue.Field(i)
+		if !fieldValue.CanSet() {
+			continue
+		}
+
+		key := FieldKey(field, tag)
+		if key == "" {
+			continue
+		}
+
+		// 上传的文件
+		switch field.Type {
+		case fileHeaderType:
+			if fhs := files[key]; len(fhs) > 0 {
+				fieldValue.Set(reflect.ValueOf(fhs[0]))
+			}
+			continue
+		case fileHeaderSliceType:
+			if fhs := files[key]; len(fhs) > 0 {
+				fieldValue.Set(reflect.ValueOf(fhs))
+			}
+			continue
+		}
+
+		values := r.PostForm[key]
+		if len(values) == 0 || fieldValue.Kind() == reflect.Struct {
+			continue
+		}
+
+		if err := setField(fieldValue, values); err != nil {
+			return &ParseError{Binding: "form", Key: key, Field: field.Name, Value: values[0], Err: err}
+		}
+	}
+
 	return nil
 }
 
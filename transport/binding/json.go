@@ -1,12 +1,16 @@
 package binding
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
+
+	"github.com/mangohow/gowlb/encoding"
+	_ "github.com/mangohow/gowlb/encoding/json"
 )
 
+// JsonBinding 使用注册的json编解码器绑定body中的参数, proto.Message 使用protojson解析
 type JsonBinding struct{}
 
 func (j JsonBinding) Bind(r *http.Request, obj any) error {
@@ -17,11 +21,15 @@ func (j JsonBinding) Bind(r *http.Request, obj any) error {
 		return errors.New("bind json error: obj is nil")
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(obj); err != nil {
+	data, err := io.ReadAll(r.Body)
+	_ = r.Body.Close()
+	if err != nil {
 		return fmt.Errorf("bind json error: %w", err)
 	}
 
-	_ = r.Body.Close()
+	if err := encoding.GetCodec("json").Unmarshal(data, obj); err != nil {
+		return fmt.Errorf("bind json error: %w", err)
+	}
 
 	return nil
 }
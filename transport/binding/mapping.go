@@ -0,0 +1,159 @@
+package binding
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ParseError 参数值无法转换为字段对应的类型
+type ParseError struct {
+	Binding string
+	Key     string
+	Field   string
+	Value   string
+	Err     error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("bind %s failed: invalid value %q of %s for field %s: %v", e.Binding, e.Value, e.Key, e.Field, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// UnknownVarError 路径变量在结构体中找不到对应的字段
+type UnknownVarError struct {
+	Binding string
+	Name    string
+}
+
+func (e *UnknownVarError) Error() string {
+	return fmt.Sprintf("bind %s failed: unknown variable %s", e.Binding, e.Name)
+}
+
+// FieldKey 获取字段对应的参数名
+// 优先使用指定的tag，其次使用json tag和protobuf tag中的name，最后使用字段名
+// oneof生成的包装结构体没有json tag，需要使用protobuf tag中的name
+// 返回空字符串表示忽略该字段
+func FieldKey(field reflect.StructField, tag string) string {
+	for _, t := range [...]string{tag, "json"} {
+		if t == "" {
+			continue
+		}
+		v, _, _ := strings.Cut(field.Tag.Get(t), ",")
+		if v == "-" {
+			return ""
+		}
+		if v != "" {
+			return v
+		}
+	}
+
+	for _, kv := range strings.Split(field.Tag.Get("protobuf"), ",") {
+		if strings.HasPrefix(kv, "name=") {
+			return kv[len("name="):]
+		}
+	}
+
+	return field.Name
+}
+
+// structElem 检查obj是否为结构体指针，并返回结构体的值
+func structElem(binding string, obj any) (reflect.Value, error) {
+	rv := reflect.ValueOf(obj)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return reflect.Value{}, fmt.Errorf("bind %s failed: obj must be a non-nil pointer", binding)
+	}
+
+	rv = rv.Elem()
+	if rv.Kind() != reflect.Struct {
+		return reflect.Value{}, fmt.Errorf("bind %s failed: obj must be a pointer of struct", binding)
+	}
+
+	return rv, nil
+}
+
+// setField 将参数值设置到字段中，支持基本类型、基本类型的切片以及它们的指针
+func setField(field reflect.Value, values []string) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.Ptr:
+		elem := reflect.New(field.Type().Elem())
+		if err := setField(elem.Elem(), values); err != nil {
+			return err
+		}
+		field.Set(elem)
+		return nil
+	case reflect.Slice:
+		slice := reflect.MakeSlice(field.Type(), len(values), len(values))
+		for i, v := range values {
+			if err := setScalar(slice.Index(i), v); err != nil {
+				return err
+			}
+		}
+		field.Set(slice)
+		return nil
+	case reflect.Array:
+		if len(values) > field.Len() {
+			return fmt.Errorf("too many values for array of length %d", field.Len())
+		}
+		for i, v := range values {
+			if err := setScalar(field.Index(i), v); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return setScalar(field, values[0])
+}
+
+func setScalar(v reflect.Value, s string) error {
+	if v.Kind() == reflect.Ptr {
+		elem := reflect.New(v.Type().Elem())
+		if err := setScalar(elem.Elem(), s); err != nil {
+			return err
+		}
+		v.Set(elem)
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+
+	return nil
+}
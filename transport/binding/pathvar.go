@@ -2,13 +2,55 @@ package binding
 
 import (
 	"net/http"
+
+	"github.com/gorilla/mux"
 )
 
-type PathVarBinding struct{}
+// PathVarBinding 绑定路径参数, 如 /api/user/:id
+// 路径参数由gorilla/mux解析, 通过Tag指定的结构体标签与字段对应, 默认为param
+type PathVarBinding struct {
+	Tag string
+}
 
 func (p PathVarBinding) Bind(r *http.Request, obj any) error {
-	//TODO implement me
-	panic("implement me")
+	vars := mux.Vars(r)
+	if len(vars) == 0 {
+		return nil
+	}
+
+	tag := p.Tag
+	if tag == "" {
+		tag = "param"
+	}
+
+	elemValue, err := structElem("path var", obj)
+	if err != nil {
+		return err
+	}
+	elemType := elemValue.Type()
+
+	fields := make(map[string]int, elemType.NumField())
+	for i := 0; i < elemType.NumField(); i++ {
+		if !elemValue.Field(i).CanSet() {
+			continue
+		}
+		if key := FieldKey(elemType.Field(i), tag); key != "" {
+			fields[key] = i
+		}
+	}
+
+	for name, value := range vars {
+		i, ok := fields[name]
+		if !ok {
+			return &UnknownVarError{Binding: "path var", Name: name}
+		}
+
+		if err := setField(elemValue.Field(i), []string{value}); err != nil {
+			return &ParseError{Binding: "path var", Key: name, Field: elemType.Field(i).Name, Value: value, Err: err}
+		}
+	}
+
+	return nil
 }
 
 func (p PathVarBinding) Name() string {
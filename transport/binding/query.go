@@ -5,7 +5,7 @@ import (
 	"net/http"
 	"net/url"
 	"reflect"
-	"strconv"
+	"strings"
 )
 
 type QueryBinding struct {
@@ -31,39 +31,49 @@ func reflectMapToObj(values url.Values, tag string, obj any) error {
 		return errors.New("bind query failed: empty tag provided")
 	}
 
-	rt := reflect.TypeOf(obj)
-	rv := reflect.ValueOf(obj)
-
-	if rt.Kind() != reflect.Ptr {
-		return errors.New("bind query failed: obj must be a pointer")
+	elemValue, err := structElem("query", obj)
+	if err != nil {
+		return err
 	}
 
-	if rt.Elem().Kind() != reflect.Struct {
-		return errors.New("bind query failed: obj must be a pointer of struct")
-	}
+	return bindValues(values, tag, elemValue, "")
+}
 
-	// 取消指针，获取结构体的类型和值
-	elemType := rt.Elem()
-	elemValue := rv.Elem()
+// bindValues 绑定结构体字段, 嵌套结构体的字段使用 . 连接的参数名, 如 page.size=10
+func bindValues(values url.Values, tag string, elemValue reflect.Value, prefix string) error {
+	elemType := elemValue.Type()
 
 	// 遍历结构体字段
 	for i := 0; i < elemType.NumField(); i++ {
 		field := elemType.Field(i)
-		fieldName := field.Name
 		fieldValue := elemValue.Field(i)
 
-		// 获取结构体标签的查询参数名
-		queryKey := field.Tag.Get(tag)
-		if queryKey == "-" {
+		// 跳过不可导出的字段
+		if !fieldValue.CanSet() {
 			continue
 		}
 
+		// 获取结构体标签的查询参数名
+		queryKey := FieldKey(field, tag)
 		if queryKey == "" {
-			queryKey = fieldName // 如果标签为空，使用字段名作为查询参数名
+			continue
 		}
+		queryKey = prefix + queryKey
 
-		// 跳过不可导出的字段
-		if !fieldValue.CanSet() {
+		// 嵌套结构体, 只有存在对应的参数时才创建
+		if t := field.Type; t.Kind() == reflect.Struct || t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Struct {
+			if !hasPrefix(values, queryKey+".") {
+				continue
+			}
+			if fieldValue.Kind() == reflect.Ptr {
+				if fieldValue.IsNil() {
+					fieldValue.Set(reflect.New(t.Elem()))
+				}
+				fieldValue = fieldValue.Elem()
+			}
+			if err := bindValues(values, tag, fieldValue, queryKey+"."); err != nil {
+				return err
+			}
 			continue
 		}
 
@@ -73,59 +83,20 @@ func reflectMapToObj(values url.Values, tag string, obj any) error {
 			continue
 		}
 
-		paramValue := paramValues[0] // 取第一个值
-
-		// 不处理嵌套结构体
-		if fieldValue.Kind() == reflect.Struct {
-			continue
+		if err := setField(fieldValue, paramValues); err != nil {
+			return &ParseError{Binding: "query", Key: queryKey, Field: field.Name, Value: paramValues[0], Err: err}
 		}
+	}
 
-		// 处理基本类型
-		switch fieldValue.Kind() {
-		case reflect.String:
-			fieldValue.SetString(paramValue)
-		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-			if v, err := strconv.ParseInt(paramValue, 10, 64); err == nil {
-				fieldValue.SetInt(v)
-			} else {
-				return errors.New("bind query failed: invalid int value for field " + fieldName)
-			}
-		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-			if v, err := strconv.ParseUint(paramValue, 10, 64); err == nil {
-				fieldValue.SetUint(v)
-			} else {
-				return errors.New("bind query failed: invalid uint value for field " + fieldName)
-			}
-		case reflect.Bool:
-			if v, err := strconv.ParseBool(paramValue); err == nil {
-				fieldValue.SetBool(v)
-			} else {
-				return errors.New("bind query failed: invalid bool value for field " + fieldName)
-			}
-		case reflect.Float32, reflect.Float64:
-			if v, err := strconv.ParseFloat(paramValue, 64); err == nil {
-				fieldValue.SetFloat(v)
-			} else {
-				return errors.New("bind query failed: invalid float value for field " + fieldName)
-			}
-		case reflect.Slice:
-			// 如果字段是 Slice 或 Array，需要解析多个值
-			if len(paramValues) > 0 {
-				// 仅处理字符串类型的 Slice
-				if fieldType := field.Type.Elem(); fieldType.Kind() == reflect.String {
-					slice := reflect.MakeSlice(reflect.SliceOf(reflect.TypeOf("")), 0, len(paramValues))
-					for _, v := range paramValues {
-						slice = reflect.Append(slice, reflect.ValueOf(v))
-					}
-					fieldValue.Set(slice)
-				} else {
-					return errors.New("bind query failed: unsupported slice/array type for field " + fieldName)
-				}
-			}
-		default:
-			return errors.New("bind query failed: unsupported field type " + fieldValue.Kind().String() + " for field " + fieldName)
+	return nil
+}
+
+func hasPrefix(values url.Values, prefix string) bool {
+	for key := range values {
+		if strings.HasPrefix(key, prefix) {
+			return true
 		}
 	}
 
-	return nil
+	return false
 }
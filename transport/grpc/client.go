@@ -0,0 +1,146 @@
+package grpc
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+
+	"github.com/mangohow/gowlb/transport/http"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+)
+
+// Interceptor 与http客户端使用相同的拦截器, 在grpc客户端中 http.CallInfoFromContext 返回false
+type Interceptor = http.Interceptor
+
+type config struct {
+	endpoint     string
+	tlsConfig    *tls.Config
+	interceptors []Interceptor
+	dialOpts     []grpc.DialOption
+}
+
+type ClientOption func(*config)
+
+// WithEndpoint 设置服务地址, 格式与grpc.NewClient的target相同, 如 localhost:9000、dns:///example.com:9000
+func WithEndpoint(endpoint string) ClientOption {
+	return func(c *config) {
+		c.endpoint = endpoint
+	}
+}
+
+// WithClientTLSConfig 使用TLS连接服务, 默认不使用TLS
+func WithClientTLSConfig(cfg *tls.Config) ClientOption {
+	return func(c *config) {
+		c.tlsConfig = cfg
+	}
+}
+
+// WithInterceptors 设置普通(unary)方法的拦截器, 按照参数顺序执行
+// 拦截器中返回的错误已经被转换为errors.Error
+func WithInterceptors(interceptors ...Interceptor) ClientOption {
+	return func(c *config) {
+		c.interceptors = append(c.interceptors, interceptors...)
+	}
+}
+
+// WithDialOptions 设置grpc的原始选项, 如负载均衡策略和keepalive
+func WithDialOptions(opts ...grpc.DialOption) ClientOption {
+	return func(c *config) {
+		c.dialOpts = append(c.dialOpts, opts...)
+	}
+}
+
+// Dial 创建grpc连接, 可以直接传给protoc-gen-go-grpc生成的NewXXXClient
+// 调用时会将context中的请求ID通过metadata传递给服务端, 服务端返回的错误会被还原为errors.Error
+func Dial(options ...ClientOption) (*grpc.ClientConn, error) {
+	c := &config{}
+	for _, option := range options {
+		option(c)
+	}
+	if c.endpoint == "" {
+		return nil, fmt.Errorf("endpoint is required")
+	}
+
+	creds := insecure.NewCredentials()
+	if c.tlsConfig != nil {
+		creds = credentials.NewTLS(c.tlsConfig)
+	}
+	opts := []grpc.DialOption{
+		grpc.WithTransportCredentials(creds),
+		grpc.WithChainUnaryInterceptor(unaryClientInterceptor(c.interceptors)),
+		grpc.WithChainStreamInterceptor(streamClientInterceptor),
+	}
+
+	return grpc.NewClient(c.endpoint, append(opts, c.dialOpts...)...)
+}
+
+func unaryClientInterceptor(interceptors []Interceptor) grpc.UnaryClientInterceptor {
+	chain := http.ChainInterceptors(interceptors...)
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		_, err := chain(outgoingContext(ctx), req, func(ctx context.Context, req any) (any, error) {
+			if err := invoker(ctx, method, req, reply, cc, opts...); err != nil {
+				return nil, FromError(err)
+			}
+			return reply, nil
+		})
+
+		return err
+	}
+}
+
+func streamClientInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	stream, err := streamer(outgoingContext(ctx), desc, cc, method, opts...)
+	if err != nil {
+		return nil, FromError(err)
+	}
+
+	return &clientStream{ClientStream: stream}, nil
+}
+
+// outgoingContext 将请求ID写入metadata, 依次从WithRequestID以及http请求的X-Request-ID头中获取
+func outgoingContext(ctx context.Context) context.Context {
+	if md, ok := metadata.FromOutgoingContext(ctx); ok && len(md.Get(RequestIDKey)) > 0 {
+		return ctx
+	}
+
+	rid := RequestIDFromContext(ctx)
+	if c := http.FromContext(ctx); rid == "" && c != nil {
+		if rid = c.ResponseWriter().Header().Get(RequestIDKey); rid == "" {
+			rid = c.Request().Header.Get(RequestIDKey)
+		}
+	}
+	if rid == "" {
+		return ctx
+	}
+
+	return metadata.AppendToOutgoingContext(ctx, RequestIDKey, rid)
+}
+
+type clientStream struct {
+	grpc.ClientStream
+}
+
+func (s *clientStream) SendMsg(m any) error {
+	return streamError(s.ClientStream.SendMsg(m))
+}
+
+func (s *clientStream) RecvMsg(m any) error {
+	return streamError(s.ClientStream.RecvMsg(m))
+}
+
+func (s *clientStream) CloseSend() error {
+	return streamError(s.ClientStream.CloseSend())
+}
+
+// streamError 流正常结束时的io.EOF原样返回
+func streamError(err error) error {
+	if err == io.EOF {
+		return err
+	}
+
+	return FromError(err)
+}
@@ -0,0 +1,178 @@
+package grpc
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/mangohow/gowlb/errors"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// ErrorDomain ErrorInfo中的domain, 用于识别由gowlb转换的错误
+	ErrorDomain = "gowlb"
+
+	// metadata中保留的key, 用于在grpc中还原错误码和http状态码
+	codeMetadataKey   = "gowlb.code"
+	statusMetadataKey = "gowlb.status"
+)
+
+// ToStatus 将err转换为grpc status, errors.Error的reason和metadata写入ErrorInfo详情中,
+// grpc状态码根据http状态码转换, grpc status原样返回, 其它错误作为服务端内部错误处理
+func ToStatus(err error) *status.Status {
+	if err == nil {
+		return status.New(codes.OK, "")
+	}
+	if st, ok := status.FromError(err); ok {
+		return st
+	}
+
+	e, ok := errors.AsError(err)
+	if !ok {
+		switch {
+		case errors.Is(err, context.Canceled):
+			return status.New(codes.Canceled, err.Error())
+		case errors.Is(err, context.DeadlineExceeded):
+			return status.New(codes.DeadlineExceeded, err.Error())
+		}
+		e = errors.FromError(errors.UnknownCode, errors.DefaultStatus, errors.UnknownReason, errors.UnknownMessage, err)
+	}
+
+	md := make(map[string]string, len(e.Metadata())+2)
+	for k, v := range e.Metadata() {
+		md[k] = v
+	}
+	md[codeMetadataKey] = strconv.Itoa(int(e.Code()))
+	md[statusMetadataKey] = strconv.Itoa(int(e.HttpStatus()))
+
+	st := status.New(GRPCCode(int(e.HttpStatus())), e.Message())
+	if s, err := st.WithDetails(&errdetails.ErrorInfo{Reason: e.Reason(), Domain: ErrorDomain, Metadata: md}); err == nil {
+		st = s
+	}
+
+	return st
+}
+
+// FromStatus 将grpc status还原为errors.Error, 没有ErrorInfo详情时,
+// 错误码为grpc状态码, reason为grpc状态码的名称, http状态码根据grpc状态码转换
+func FromStatus(st *status.Status) errors.Error {
+	for _, detail := range st.Details() {
+		info, ok := detail.(*errdetails.ErrorInfo)
+		if !ok {
+			continue
+		}
+
+		code, httpStatus := int32(st.Code()), int32(HTTPStatus(st.Code()))
+		md := make(map[string]string, len(info.Metadata))
+		for k, v := range info.Metadata {
+			switch k {
+			case codeMetadataKey:
+				if n, err := strconv.Atoi(v); err == nil {
+					code = int32(n)
+				}
+			case statusMetadataKey:
+				if n, err := strconv.Atoi(v); err == nil {
+					httpStatus = int32(n)
+				}
+			default:
+				md[k] = v
+			}
+		}
+
+		e := errors.New(code, httpStatus, info.Reason, st.Message())
+		if len(md) > 0 {
+			e = errors.WithMetadata(e, md)
+		}
+		return e
+	}
+
+	return errors.New(int32(st.Code()), int32(HTTPStatus(st.Code())), st.Code().String(), st.Message())
+}
+
+// FromError 将客户端调用返回的grpc错误转换为errors.Error, 不是grpc status的错误原样返回
+func FromError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := errors.AsError(err); ok {
+		return err
+	}
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+
+	return FromStatus(st)
+}
+
+// GRPCCode 将http状态码转换为grpc状态码
+func GRPCCode(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusOK:
+		return codes.OK
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.Aborted
+	case http.StatusPreconditionFailed:
+		return codes.FailedPrecondition
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case 499:
+		return codes.Canceled
+	case http.StatusNotImplemented:
+		return codes.Unimplemented
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	case http.StatusGatewayTimeout:
+		return codes.DeadlineExceeded
+	}
+
+	switch {
+	case httpStatus >= 500:
+		return codes.Internal
+	case httpStatus >= 400:
+		return codes.InvalidArgument
+	}
+
+	return codes.Unknown
+}
+
+// HTTPStatus 将grpc状态码转换为http状态码, 与grpc-gateway相同
+func HTTPStatus(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return 499
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	}
+
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,171 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/mangohow/gowlb/errors"
+	"github.com/mangohow/gowlb/llog"
+	"github.com/mangohow/gowlb/transport/http"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+var errNotFound = errors.New(404, http.StatusNotFound, "UserNotFound", "user not found")
+
+type echoServer interface {
+	Echo(ctx context.Context, req *wrapperspb.StringValue) (*wrapperspb.StringValue, error)
+}
+
+type echo struct{}
+
+func (echo) Echo(ctx context.Context, req *wrapperspb.StringValue) (*wrapperspb.StringValue, error) {
+	switch req.Value {
+	case "missing":
+		return nil, errors.WithMetadata(errNotFound, map[string]string{"id": "1"})
+	case "denied":
+		return nil, status.Error(codes.PermissionDenied, "denied")
+	}
+
+	op, _ := http.OperationFromContext(ctx)
+	return wrapperspb.String(op.FullName() + " " + RequestIDFromContext(ctx) + " " + req.Value), nil
+}
+
+var echoServiceDesc = grpc.ServiceDesc{
+	ServiceName: "test.Echo",
+	HandlerType: (*echoServer)(nil),
+	Methods: []grpc.MethodDesc{{
+		MethodName: "Echo",
+		Handler: func(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+			in := new(wrapperspb.StringValue)
+			if err := dec(in); err != nil {
+				return nil, err
+			}
+			info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/test.Echo/Echo"}
+			return interceptor(ctx, in, info, func(ctx context.Context, req any) (any, error) {
+				return srv.(echoServer).Echo(ctx, req.(*wrapperspb.StringValue))
+			})
+		},
+	}},
+}
+
+func TestServerClient(t *testing.T) {
+	ln := bufconn.Listen(1 << 20)
+	s := New(WithListener(ln))
+	var ops []string
+	s.Middleware(func(ctx context.Context, req any, handler http.Handler) (any, error) {
+		op, _ := http.OperationFromContext(ctx)
+		ops = append(ops, op.Method)
+		return handler(ctx, req)
+	})
+	s.RegisterService(&echoServiceDesc, echo{})
+	go s.Start()
+	defer s.Stop(context.Background())
+
+	var calls int
+	cc, err := Dial(WithEndpoint("passthrough:///bufnet"),
+		WithDialOptions(grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return ln.DialContext(ctx)
+		})),
+		WithInterceptors(func(ctx context.Context, req any, invoker http.Invoker) (any, error) {
+			calls++
+			return invoker(ctx, req)
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	invoke := func(ctx context.Context, value string, opts ...grpc.CallOption) (*wrapperspb.StringValue, error) {
+		reply := new(wrapperspb.StringValue)
+		err := cc.Invoke(ctx, "/test.Echo/Echo", wrapperspb.String(value), reply, opts...)
+		return reply, err
+	}
+
+	var header metadata.MD
+	reply, err := invoke(WithRequestID(ctx, "rid-1"), "hi", grpc.Header(&header))
+	if err != nil || reply.Value != "/test.Echo/Echo rid-1 hi" {
+		t.Fatalf("Echo() = %v, %v", reply, err)
+	}
+	if rid := header.Get(RequestIDKey); len(rid) != 1 || rid[0] != "rid-1" {
+		t.Errorf("response request id = %v", rid)
+	}
+	if len(ops) != 1 || ops[0] != "Echo" || calls != 1 {
+		t.Errorf("middleware ops = %v, interceptor calls = %d", ops, calls)
+	}
+
+	_, err = invoke(ctx, "missing")
+	e, ok := errors.AsError(err)
+	if !ok || !errors.Is(err, errNotFound) || e.HttpStatus() != http.StatusNotFound ||
+		e.Message() != "user not found" || len(e.Metadata()) != 1 || e.Metadata()["id"] != "1" {
+		t.Errorf("Echo(missing) err = %v", err)
+	}
+
+	_, err = invoke(ctx, "denied")
+	if e, ok := errors.AsError(err); !ok || e.Reason() != "PermissionDenied" || e.HttpStatus() != 403 {
+		t.Errorf("Echo(denied) err = %v", err)
+	}
+}
+
+func TestStatusMapping(t *testing.T) {
+	st := ToStatus(errors.New(1001, 409, "Exists", "already exists"))
+	if st.Code() != codes.Aborted || st.Message() != "already exists" {
+		t.Errorf("ToStatus() = %v", st)
+	}
+
+	st = ToStatus(context.DeadlineExceeded)
+	if st.Code() != codes.DeadlineExceeded {
+		t.Errorf("ToStatus(deadline) = %v", st)
+	}
+
+	e := FromStatus(ToStatus(net.ErrClosed))
+	if e.Code() != errors.UnknownCode || e.Reason() != errors.UnknownReason || e.HttpStatus() != errors.DefaultStatus {
+		t.Errorf("FromStatus(unknown) = %v", e)
+	}
+
+	for _, code := range []codes.Code{codes.InvalidArgument, codes.NotFound, codes.Unavailable, codes.Unauthenticated} {
+		if got := GRPCCode(HTTPStatus(code)); got != code {
+			t.Errorf("GRPCCode(HTTPStatus(%v)) = %v", code, got)
+		}
+	}
+}
+
+// http.FromContext 在grpc服务中返回nil, llog的中间件需要能够同时用于http和grpc
+func TestServerLogMiddleware(t *testing.T) {
+	ln := bufconn.Listen(1 << 20)
+	s := New(WithListener(ln))
+	s.Middleware(llog.LoggerInjectMiddleware(""), llog.RequestLoggingMiddleware())
+	s.RegisterService(&echoServiceDesc, echo{})
+	go s.Start()
+	defer s.Stop(context.Background())
+
+	cc, err := Dial(WithEndpoint("passthrough:///bufnet"),
+		WithDialOptions(grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return ln.DialContext(ctx)
+		})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	reply := new(wrapperspb.StringValue)
+	if err := cc.Invoke(WithRequestID(ctx, "rid-1"), "/test.Echo/Echo", wrapperspb.String("hi"), reply); err != nil ||
+		reply.Value != "/test.Echo/Echo rid-1 hi" {
+		t.Errorf("Echo() = %v, %v", reply, err)
+	}
+
+	err = cc.Invoke(ctx, "/test.Echo/Echo", wrapperspb.String("missing"), reply)
+	if !errors.Is(err, errNotFound) {
+		t.Errorf("Echo(missing) err = %v", err)
+	}
+}
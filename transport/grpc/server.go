@@ -0,0 +1,218 @@
+package grpc
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/mangohow/gowlb/llog"
+	"github.com/mangohow/gowlb/transport/http"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+)
+
+// RequestIDKey 传递请求ID使用的metadata key, 与http的X-Request-ID头对应
+const RequestIDKey = "x-request-id"
+
+// Middleware 与http使用相同的中间件, 同一个中间件可以同时用于http和grpc服务
+// 在grpc服务中 http.FromContext 返回nil, 中间件需要处理这种情况, 可以通过 http.OperationFromContext 获取当前调用的方法
+// 中间件只对普通(unary)方法生效, 流式方法不经过中间件
+type Middleware = http.Middleware
+
+type Server struct {
+	server *grpc.Server
+	addr   string
+
+	log         *logrus.Logger
+	tlsConfig   *tls.Config
+	listener    net.Listener
+	grpcOpts    []grpc.ServerOption
+	middlewares []Middleware
+}
+
+type Option func(s *Server)
+
+// WithAddr 设置监听地址, 默认为 :9000
+func WithAddr(addr string) Option {
+	return func(s *Server) {
+		s.addr = addr
+	}
+}
+
+// WithListener 使用指定的listener, 设置后忽略WithAddr, 可以用于在测试中注入内存listener
+func WithListener(listener net.Listener) Option {
+	return func(s *Server) {
+		s.listener = listener
+	}
+}
+
+func WithLogger(log *logrus.Logger) Option {
+	return func(s *Server) {
+		s.log = log
+	}
+}
+
+// WithTLSConfig 使用TLS启动服务
+func WithTLSConfig(cfg *tls.Config) Option {
+	return func(s *Server) {
+		s.tlsConfig = cfg
+	}
+}
+
+// WithServerOptions 设置grpc.Server的原始选项, 如消息大小限制和keepalive
+func WithServerOptions(opts ...grpc.ServerOption) Option {
+	return func(s *Server) {
+		s.grpcOpts = append(s.grpcOpts, opts...)
+	}
+}
+
+func New(opts ...Option) *Server {
+	s := &Server{}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if s.addr == "" {
+		s.addr = ":9000"
+	}
+
+	if s.log == nil {
+		s.log = logrus.StandardLogger()
+	}
+
+	grpcOpts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(s.unaryInterceptor),
+		grpc.ChainStreamInterceptor(s.streamInterceptor),
+	}
+	if s.tlsConfig != nil {
+		grpcOpts = append(grpcOpts, grpc.Creds(credentials.NewTLS(s.tlsConfig)))
+	}
+	s.server = grpc.NewServer(append(grpcOpts, s.grpcOpts...)...)
+
+	return s
+}
+
+func (s *Server) GrpcServer() *grpc.Server {
+	return s.server
+}
+
+// RegisterService 实现grpc.ServiceRegistrar, 可以直接传给protoc-gen-go-grpc生成的RegisterXXXServer
+func (s *Server) RegisterService(sd *grpc.ServiceDesc, srv interface{}) {
+	s.server.RegisterService(sd, srv)
+}
+
+// Middleware 添加中间件, 只对普通(unary)方法生效
+func (s *Server) Middleware(middleware ...Middleware) {
+	s.middlewares = append(s.middlewares, middleware...)
+}
+
+func (s *Server) Start() error {
+	ln := s.listener
+	if ln == nil {
+		var err error
+		if ln, err = net.Listen("tcp", s.addr); err != nil {
+			return err
+		}
+	}
+
+	s.log.Info("grpc server listen at ", ln.Addr())
+	err := s.server.Serve(ln)
+	if err == grpc.ErrServerStopped {
+		return nil
+	}
+
+	return err
+}
+
+// Stop 等待正在处理的请求结束后关闭服务, ctx结束时强制关闭
+func (s *Server) Stop(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.server.Stop()
+		return ctx.Err()
+	}
+}
+
+func (s *Server) unaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx = s.newContext(ctx, info.FullMethod)
+	resp, err := http.Chain(s.middlewares...)(ctx, req, http.Handler(handler))
+	if err != nil {
+		return nil, ToStatus(err).Err()
+	}
+
+	return resp, nil
+}
+
+func (s *Server) streamInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx := s.newContext(ss.Context(), info.FullMethod)
+	if err := handler(srv, &serverStream{ServerStream: ss, ctx: ctx}); err != nil {
+		return ToStatus(err).Err()
+	}
+
+	return nil
+}
+
+// newContext 在ctx中设置当前调用的方法, 并注入带有请求ID的logger, 请求ID通过响应头返回
+func (s *Server) newContext(ctx context.Context, fullMethod string) context.Context {
+	service, method, _ := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	ctx = http.NewOperationContext(ctx, http.Operation{
+		Service:    service,
+		Method:     method,
+		HTTPMethod: "POST",
+		Pattern:    fullMethod,
+	})
+
+	var rid string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get(RequestIDKey); len(v) > 0 {
+			rid = v[0]
+		}
+	}
+	if rid == "" {
+		rid = uuid.New().String()
+	}
+	if err := grpc.SetHeader(ctx, metadata.Pairs(RequestIDKey, rid)); err != nil {
+		s.log.Debugf("grpc: set request id header for %s: %v", fullMethod, err)
+	}
+
+	ctx = WithRequestID(ctx, rid)
+	if logger := llog.FromContext(ctx); logger != nil {
+		ctx = llog.WithLogger(ctx, logger.With("requestId", rid))
+	}
+
+	return ctx
+}
+
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+type requestIDKey struct{}
+
+// WithRequestID 将请求ID写入context, 客户端发起调用时通过metadata传递给服务端
+func WithRequestID(ctx context.Context, rid string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, rid)
+}
+
+// RequestIDFromContext 获取当前请求的ID, 在服务端为客户端传递或者自动生成的ID
+func RequestIDFromContext(ctx context.Context) string {
+	rid, _ := ctx.Value(requestIDKey{}).(string)
+	return rid
+}
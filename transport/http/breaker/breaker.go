@@ -0,0 +1,339 @@
+package breaker
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/mangohow/gowlb/errors"
+	"github.com/mangohow/gowlb/transport/http"
+)
+
+const (
+	ReasonCircuitOpen = "CircuitOpen"
+)
+
+// State 熔断器状态
+type State int
+
+const (
+	// StateClosed 正常放行请求, 统计窗口内的错误率或慢请求比例超过阈值时打开
+	StateClosed State = iota
+	// StateOpen 拒绝所有请求, 经过OpenTimeout后进入半开状态
+	StateOpen
+	// StateHalfOpen 放行少量探测请求, 全部成功后关闭, 任意一个失败后重新打开
+	StateHalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	}
+
+	return "unknown"
+}
+
+// StateChangeFunc 状态变化时调用, endpoint为CallInfo.Endpoint
+type StateChangeFunc func(endpoint string, from, to State)
+
+type options struct {
+	window           time.Duration
+	buckets          int
+	minRequests      int
+	errorRate        float64
+	slowThreshold    time.Duration
+	slowRate         float64
+	openTimeout      time.Duration
+	halfOpenRequests int
+	isFailure        func(err error) bool
+	onStateChange    StateChangeFunc
+}
+
+type Option func(*options)
+
+// WithWindow 统计窗口, 默认为10s, 窗口被划分为10个桶滑动
+func WithWindow(window time.Duration) Option {
+	return func(o *options) {
+		o.window = window
+	}
+}
+
+// WithMinRequests 统计窗口内的请求数达到n之后才会打开, 默认为20
+func WithMinRequests(n int) Option {
+	return func(o *options) {
+		o.minRequests = n
+	}
+}
+
+// WithErrorRate 统计窗口内的错误率达到rate时打开, 默认为0.5
+func WithErrorRate(rate float64) Option {
+	return func(o *options) {
+		o.errorRate = rate
+	}
+}
+
+// WithSlowCall 耗时超过threshold的请求视为慢请求, 统计窗口内慢请求的比例达到rate时打开, 默认不启用
+func WithSlowCall(threshold time.Duration, rate float64) Option {
+	return func(o *options) {
+		o.slowThreshold = threshold
+		o.slowRate = rate
+	}
+}
+
+// WithOpenTimeout 打开状态持续的时间, 之后进入半开状态, 默认为5s
+func WithOpenTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.openTimeout = timeout
+	}
+}
+
+// WithHalfOpenRequests 半开状态下放行的探测请求数, 默认为1
+func WithHalfOpenRequests(n int) Option {
+	return func(o *options) {
+		o.halfOpenRequests = n
+	}
+}
+
+// WithFailureFunc 判断请求是否失败, 默认网络错误、超时以及5xx视为失败, 4xx和调用方取消不视为失败
+func WithFailureFunc(fn func(err error) bool) Option {
+	return func(o *options) {
+		o.isFailure = fn
+	}
+}
+
+// WithStateChange 设置状态变化时的回调函数, 可用于记录日志和告警
+func WithStateChange(fn StateChangeFunc) Option {
+	return func(o *options) {
+		o.onStateChange = fn
+	}
+}
+
+// Client 按照CallInfo.Endpoint为每个服务实例创建熔断器,
+// 熔断器打开时直接返回 errors.ServiceUnavailable, 不会发送请求
+// 配合WithResolver使用时, 可以将ReasonCircuitOpen添加到RetryPolicy.RetryableReasons中, 重试时选择其它实例
+func Client(opts ...Option) http.Interceptor {
+	o := options{
+		window:           10 * time.Second,
+		buckets:          10,
+		minRequests:      20,
+		errorRate:        0.5,
+		openTimeout:      5 * time.Second,
+		halfOpenRequests: 1,
+		isFailure:        isFailure,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.halfOpenRequests <= 0 {
+		o.halfOpenRequests = 1
+	}
+
+	var (
+		mu       sync.Mutex
+		breakers = make(map[string]*breaker)
+	)
+	get := func(endpoint string) *breaker {
+		mu.Lock()
+		defer mu.Unlock()
+
+		b, ok := breakers[endpoint]
+		if !ok {
+			b = newBreaker(endpoint, &o)
+			breakers[endpoint] = b
+		}
+		return b
+	}
+
+	return func(ctx context.Context, req any, invoker http.Invoker) (any, error) {
+		var endpoint string
+		if info, ok := http.CallInfoFromContext(ctx); ok {
+			endpoint = info.Endpoint
+		}
+
+		b := get(endpoint)
+		token, err := b.allow()
+		if err != nil {
+			return nil, err
+		}
+
+		start := time.Now()
+		resp, err := invoker(ctx, req)
+		slow := o.slowThreshold > 0 && time.Since(start) >= o.slowThreshold
+		b.done(token, o.isFailure(err), slow)
+
+		return resp, err
+	}
+}
+
+func isFailure(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if e, ok := errors.AsError(err); ok {
+		return e.HttpStatus() >= http.StatusInternalServerError
+	}
+
+	return true
+}
+
+type bucket struct {
+	// 桶对应的时间段序号, 与当前序号相差超过桶数量时视为过期
+	epoch    int64
+	total    int
+	failures int
+	slow     int
+}
+
+// token 记录请求开始时熔断器所处的状态, 状态变化之前发出的请求结束时不再统计
+type token struct {
+	gen   uint64
+	probe bool
+}
+
+type breaker struct {
+	endpoint string
+	o        *options
+
+	mu       sync.Mutex
+	state    State
+	gen      uint64
+	openedAt time.Time
+	buckets  []bucket
+	// 半开状态下正在进行和已经成功的探测请求数
+	probes    int
+	successes int
+}
+
+func newBreaker(endpoint string, o *options) *breaker {
+	return &breaker{
+		endpoint: endpoint,
+		o:        o,
+		buckets:  make([]bucket, o.buckets),
+	}
+}
+
+func (b *breaker) allow() (token, error) {
+	b.mu.Lock()
+	var changed []State
+	if b.state == StateOpen && time.Since(b.openedAt) >= b.o.openTimeout {
+		changed = b.setState(StateHalfOpen)
+	}
+
+	t := token{gen: b.gen}
+	var err error
+	switch b.state {
+	case StateOpen:
+		err = errors.ServiceUnavailable(errors.UnknownCode, ReasonCircuitOpen, "circuit breaker is open for "+b.endpoint)
+	case StateHalfOpen:
+		if b.probes >= b.o.halfOpenRequests {
+			err = errors.ServiceUnavailable(errors.UnknownCode, ReasonCircuitOpen, "circuit breaker is half-open for "+b.endpoint)
+		} else {
+			b.probes++
+			t.probe = true
+		}
+	}
+	b.mu.Unlock()
+
+	b.notify(changed)
+	return t, err
+}
+
+func (b *breaker) done(t token, failure, slow bool) {
+	b.mu.Lock()
+	if t.gen != b.gen {
+		b.mu.Unlock()
+		return
+	}
+
+	var changed []State
+	switch {
+	case t.probe && (failure || slow):
+		changed = b.setState(StateOpen)
+	case t.probe:
+		b.successes++
+		if b.successes >= b.o.halfOpenRequests {
+			changed = b.setState(StateClosed)
+		}
+	case b.state == StateClosed:
+		b.record(failure, slow)
+		if b.tripped() {
+			changed = b.setState(StateOpen)
+		}
+	}
+	b.mu.Unlock()
+
+	b.notify(changed)
+}
+
+// setState 切换状态并清空统计信息, 返回[from, to]
+func (b *breaker) setState(state State) []State {
+	from := b.state
+	b.state = state
+	b.gen++
+	b.probes, b.successes = 0, 0
+	for i := range b.buckets {
+		b.buckets[i] = bucket{}
+	}
+	if state == StateOpen {
+		b.openedAt = time.Now()
+	}
+
+	return []State{from, state}
+}
+
+func (b *breaker) notify(changed []State) {
+	if changed != nil && b.o.onStateChange != nil {
+		b.o.onStateChange(b.endpoint, changed[0], changed[1])
+	}
+}
+
+func (b *breaker) epoch() int64 {
+	width := int64(b.o.window) / int64(len(b.buckets))
+	if width <= 0 {
+		width = 1
+	}
+
+	return time.Now().UnixNano() / width
+}
+
+func (b *breaker) record(failure, slow bool) {
+	epoch := b.epoch()
+	bk := &b.buckets[epoch%int64(len(b.buckets))]
+	if bk.epoch != epoch {
+		*bk = bucket{epoch: epoch}
+	}
+	bk.total++
+	if failure {
+		bk.failures++
+	}
+	if slow {
+		bk.slow++
+	}
+}
+
+// tripped 统计窗口内的错误率或者慢请求比例是否超过阈值
+func (b *breaker) tripped() bool {
+	epoch := b.epoch()
+	var total, failures, slow int
+	for _, bk := range b.buckets {
+		if epoch-bk.epoch < int64(len(b.buckets)) {
+			total += bk.total
+			failures += bk.failures
+			slow += bk.slow
+		}
+	}
+	if total == 0 || total < b.o.minRequests {
+		return false
+	}
+
+	if float64(failures)/float64(total) >= b.o.errorRate {
+		return true
+	}
+
+	return b.o.slowRate > 0 && float64(slow)/float64(total) >= b.o.slowRate
+}
@@ -0,0 +1,142 @@
+package breaker
+
+import (
+	"context"
+	"io"
+	nethttp "net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mangohow/gowlb/balancer"
+	"github.com/mangohow/gowlb/errors"
+	"github.com/mangohow/gowlb/resolver"
+	"github.com/mangohow/gowlb/transport/http"
+)
+
+func TestClient(t *testing.T) {
+	var healthy, calls int32
+	srv := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		atomic.AddInt32(&calls, 1)
+		if atomic.LoadInt32(&healthy) == 0 {
+			w.WriteHeader(nethttp.StatusInternalServerError)
+			return
+		}
+		io.WriteString(w, `{"data":null,"error":null}`)
+	}))
+	defer srv.Close()
+
+	var changes []string
+	client, err := http.NewClient(
+		http.WithEndpoint(srv.URL),
+		http.WithInterceptors(Client(
+			WithMinRequests(4),
+			WithErrorRate(0.5),
+			WithOpenTimeout(50*time.Millisecond),
+			WithStateChange(func(endpoint string, from, to State) {
+				changes = append(changes, from.String()+"->"+to.String())
+			}),
+		)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	invoke := func() error {
+		_, err := client.Invoke(context.Background(), nethttp.MethodGet, "/", nil, nil)
+		return err
+	}
+
+	for i := 0; i < 4; i++ {
+		if err := invoke(); err == nil {
+			t.Fatal("invoke should fail")
+		}
+	}
+	err = invoke()
+	if e, ok := errors.AsError(err); !ok || e.Reason() != ReasonCircuitOpen || e.Code() != errors.UnknownCode ||
+		e.HttpStatus() != nethttp.StatusServiceUnavailable {
+		t.Fatalf("invoke = %v, want circuit open", err)
+	}
+	if calls != 4 {
+		t.Fatalf("calls = %d, want 4", calls)
+	}
+
+	// 半开状态下探测失败, 重新打开
+	time.Sleep(60 * time.Millisecond)
+	if e, ok := errors.AsError(invoke()); !ok || e.HttpStatus() != nethttp.StatusInternalServerError {
+		t.Fatalf("probe = %v, want server error", e)
+	}
+
+	// 探测成功后关闭
+	atomic.StoreInt32(&healthy, 1)
+	time.Sleep(60 * time.Millisecond)
+	for i := 0; i < 3; i++ {
+		if err := invoke(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := []string{"closed->open", "open->half-open", "half-open->open", "open->half-open", "half-open->closed"}
+	if len(changes) != len(want) {
+		t.Fatalf("changes = %v, want %v", changes, want)
+	}
+	for i := range want {
+		if changes[i] != want[i] {
+			t.Fatalf("changes = %v, want %v", changes, want)
+		}
+	}
+}
+
+func TestClientRetryOtherEndpoint(t *testing.T) {
+	var badCalls, goodCalls int32
+	bad := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		atomic.AddInt32(&badCalls, 1)
+		w.WriteHeader(nethttp.StatusInternalServerError)
+	}))
+	defer bad.Close()
+	good := httptest.NewServer(nethttp.HandlerFunc(func(w nethttp.ResponseWriter, r *nethttp.Request) {
+		atomic.AddInt32(&goodCalls, 1)
+		io.WriteString(w, `{"data":null,"error":null}`)
+	}))
+	defer good.Close()
+
+	// 500不重试, 只有熔断器拒绝的请求才会重试并选择其它实例
+	client, err := http.NewClient(
+		http.WithResolver(resolver.Static(bad.URL, good.URL)),
+		http.WithBalancer(balancer.RoundRobin()),
+		http.WithRetryPolicy(http.RetryPolicy{
+			MaxAttempts:      2,
+			InitialBackoff:   time.Millisecond,
+			RetryableReasons: []string{ReasonCircuitOpen},
+		}),
+		http.WithInterceptors(Client(WithMinRequests(2), WithErrorRate(0.5), WithOpenTimeout(time.Minute))),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	invoke := func() error {
+		_, err := client.Invoke(context.Background(), nethttp.MethodGet, "/", nil, nil)
+		return err
+	}
+
+	var failed int
+	for i := 0; i < 4; i++ {
+		if invoke() != nil {
+			failed++
+		}
+	}
+	if failed != 2 || badCalls != 2 {
+		t.Fatalf("failed = %d, bad calls = %d, want 2 and 2", failed, badCalls)
+	}
+
+	// bad的熔断器已经打开, 选中bad的请求被拒绝后重试到good
+	for i := 0; i < 6; i++ {
+		if err := invoke(); err != nil {
+			t.Fatalf("invoke %d: %v", i, err)
+		}
+	}
+	if badCalls != 2 || goodCalls != 8 {
+		t.Errorf("bad calls = %d, good calls = %d, want 2 and 8", badCalls, goodCalls)
+	}
+}
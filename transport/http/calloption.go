@@ -3,6 +3,8 @@ package http
 import "net/http"
 
 type BeforeCallInfo struct {
+	// rpc方法全名, 如 /helloworld.v1.Greeter/SayHello
+	Operation   string
 	ContentType string
 	Header      http.Header
 	Value       interface{}
@@ -52,3 +54,17 @@ func (c headerCallOption) Before(info *BeforeCallInfo) {
 func HeadersCallOption(headers http.Header) CallOption {
 	return headerCallOption{headers: headers}
 }
+
+type operationCallOption struct {
+	EmptyCallOptions
+	operation string
+}
+
+func (o operationCallOption) Before(info *BeforeCallInfo) {
+	info.Operation = o.operation
+}
+
+// OperationCallOption 设置请求对应的rpc方法全名, 拦截器中可以通过CallInfo.Operation获取
+func OperationCallOption(operation string) CallOption {
+	return operationCallOption{operation: operation}
+}
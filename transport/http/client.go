@@ -3,29 +3,47 @@ package http
 import (
 	"bytes"
 	"context"
-	"encoding/json"
-	"errors"
+	"crypto/tls"
+	"fmt"
 	"io"
 	"net/http"
-	"reflect"
-	"strconv"
-	"strings"
+	"sync"
+
+	"github.com/mangohow/gowlb/balancer"
+	"github.com/mangohow/gowlb/encoding"
+	"github.com/mangohow/gowlb/encoding/json"
+	"github.com/mangohow/gowlb/errors"
+	"github.com/mangohow/gowlb/resolver"
+	"github.com/mangohow/gowlb/serialize"
 )
 
 // Client http client
 type Client struct {
 	client *http.Client
 	config config
+
+	pool      *balancer.Pool
+	watcher   resolver.Watcher
+	closed    chan struct{}
+	closeOnce sync.Once
 }
 
 type config struct {
 	host         string
 	transport    http.RoundTripper
 	interceptors []Interceptor
-}
 
-// Interceptor 拦截器
-type Interceptor func() error
+	tlsConfig      *tls.Config
+	rootCAFile     string
+	clientCertFile string
+	clientKeyFile  string
+
+	retryPolicy *RetryPolicy
+
+	resolver    resolver.Resolver
+	balancer    balancer.Balancer
+	poolOptions []balancer.PoolOption
+}
 
 type ClientOption func(*config)
 
@@ -37,10 +55,26 @@ func NewClient(options ...ClientOption) (*Client, error) {
 		option(&c.config)
 	}
 
-	c.client.Transport = c.config.transport
+	if c.config.host == "" && c.config.resolver == nil {
+		return nil, fmt.Errorf("host is required, use WithEndpoint or WithResolver option to set host")
+	}
+
+	transport, err := c.config.buildTransport()
+	if err != nil {
+		return nil, err
+	}
+	c.client.Transport = transport
 
-	if c.config.host == "" {
-		return nil, errors.New("host is required, use WithHost option to set host")
+	if c.config.resolver != nil {
+		if c.config.host == "" {
+			c.config.host = "http://"
+			if c.config.tlsConfig != nil || c.config.rootCAFile != "" || c.config.clientCertFile != "" {
+				c.config.host = "https://"
+			}
+		}
+		if err := c.watchEndpoints(); err != nil {
+			return nil, err
+		}
 	}
 
 	return c, nil
@@ -58,64 +92,93 @@ func WithTransport(transport http.RoundTripper) ClientOption {
 	}
 }
 
+// WithClientTLSConfig 设置TLS配置, 需要与默认的transport或*http.Transport一起使用
+func WithClientTLSConfig(cfg *tls.Config) ClientOption {
+	return func(c *config) {
+		c.tlsConfig = cfg
+	}
+}
+
+// WithRootCAFile 使用caFile验证服务端证书
+func WithRootCAFile(caFile string) ClientOption {
+	return func(c *config) {
+		c.rootCAFile = caFile
+	}
+}
+
+// WithClientCertFiles 设置mTLS中的客户端证书, 证书文件修改后会自动重新加载
+func WithClientCertFiles(certFile, keyFile string) ClientOption {
+	return func(c *config) {
+		c.clientCertFile = certFile
+		c.clientKeyFile = keyFile
+	}
+}
+
+// WithInterceptors 添加客户端拦截器, 按照添加顺序执行
 func WithInterceptors(interceptors ...Interceptor) ClientOption {
 	return func(c *config) {
 		c.interceptors = append(c.interceptors, interceptors...)
 	}
 }
 
-// Invoke 先执行全局拦截器，再执行CallOption中的before，最后再发起请求
-func (c *Client) Invoke(ctx context.Context, method, path string, req, resp interface{}, opts ...CallOption) (status int, err error) {
-	url := c.config.host + path
+// buildTransport 根据TLS相关的选项生成transport
+func (c *config) buildTransport() (http.RoundTripper, error) {
+	if c.tlsConfig == nil && c.rootCAFile == "" && c.clientCertFile == "" {
+		return c.transport, nil
+	}
 
-	bco := &BeforeCallInfo{
-		Header: make(http.Header),
-		Value:  req,
+	var transport *http.Transport
+	switch t := c.transport.(type) {
+	case nil:
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	case *http.Transport:
+		transport = t.Clone()
+	default:
+		return nil, fmt.Errorf("tls options require *http.Transport, got %T", c.transport)
 	}
-	for _, opt := range opts {
-		opt.Before(bco)
+
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if c.tlsConfig != nil {
+		cfg = c.tlsConfig.Clone()
+	} else if transport.TLSClientConfig != nil {
+		cfg = transport.TLSClientConfig.Clone()
 	}
 
-	var bodyReader io.Reader
-	if req != nil {
-		bodyBytes, err := json.Marshal(req)
+	if c.rootCAFile != "" {
+		pool, err := loadCertPool(c.rootCAFile)
 		if err != nil {
-			return status, err
+			return nil, err
 		}
-		bodyReader = bytes.NewReader(bodyBytes)
-	}
-	request, err := http.NewRequest(method, url, bodyReader)
-	if err != nil {
-		return
+		cfg.RootCAs = pool
 	}
 
-	if bco.ContentType == "" && method != http.MethodGet {
-		request.Header.Set("Content-Type", "application/json")
-	} else {
-		request.Header.Set("Content-Type", bco.ContentType)
-	}
-	for k, v := range bco.Header {
-		request.Header.Set(k, v[0])
+	if c.clientCertFile != "" {
+		reloader, err := newCertReloader(c.clientCertFile, c.clientKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.GetClientCertificate = reloader.GetClientCertificate
 	}
+	transport.TLSClientConfig = cfg
+
+	return transport, nil
+}
 
-	response, err := c.client.Do(request)
+// Invoke 先执行CallOption中的before, 再通过拦截器发起请求, 最后执行CallOption中的after
+func (c *Client) Invoke(ctx context.Context, method, path string, req, resp interface{}, opts ...CallOption) (status int, err error) {
+	request, bco, err := c.newRequest(ctx, method, path, req, "application/json", opts)
 	if err != nil {
 		return
 	}
-	defer response.Body.Close()
-	status = response.StatusCode
 
-	respBytes, err := io.ReadAll(response.Body)
+	info, err := c.do(request, bco.Operation, req, resp)
+	if info.Response != nil {
+		status = info.Response.StatusCode
+	}
 	if err != nil {
 		return
 	}
 
-	if resp != nil && len(respBytes) > 0 && status >= 200 && status < 400 {
-		if err = json.Unmarshal(respBytes, resp); err != nil {
-			return
-		}
-	}
-
 	aco := &AfterCallInfo{
 		Resp:   resp,
 		Status: status,
@@ -128,153 +191,78 @@ func (c *Client) Invoke(ctx context.Context, method, path string, req, resp inte
 	return
 }
 
-func EncodeURL(pattern string, obj interface{}, query bool) string {
-	strings.TrimSuffix(pattern, "/")
-	if pattern == "" || obj == nil {
-		return ""
+// decodeReply 服务端返回的结果被包装在serialize.Response中
+func decodeReply(response *http.Response, body []byte, reply any) error {
+	status := response.StatusCode
+	if reply == nil || len(body) == 0 || status < 200 || status >= 400 {
+		return nil
 	}
 
-	var (
-		builder = &strings.Builder{}
-		encPam  = false
-		m       = make(map[string]string, 8)
-	)
-	i := strings.IndexByte(pattern, ':')
-	if i != -1 {
-		// /xxx/:yyy/:zzz
-		// /xxx
-		builder.WriteString(pattern[:i-1])
-		encPam = true
-	} else {
-		// /xxx/yyy
-		// /xxx/yyy
-		builder.WriteString(pattern)
-	}
-	// 从obj中获取param来拼接路径
-	if encPam {
-		// /:yyy/:zzz
-		encodeParam(pattern[i-1:], obj, builder, m)
+	codec := encoding.GetCodecByContentType(response.Header.Get("Content-Type"))
+	if codec == nil {
+		codec = encoding.GetCodec(json.Name)
 	}
 
-	// 从obj中获取form参数来拼接路径
-	// 如果对应的field字段为空，则不拼接
-	if query {
-		encodeQuery(obj, builder, m)
-	}
+	return codec.Unmarshal(body, &serialize.Response{Data: reply})
+}
 
-	return builder.String()
+// responseError 解析失败响应中的错误, 无法解析时返回包含状态码和响应体的错误
+func responseError(response *http.Response) error {
+	body, _ := io.ReadAll(response.Body)
+	return errorFromBody(response, body)
 }
 
-func EncodeURLFromForm(pattern string, obj interface{}) string {
-	if pattern == "" || obj == nil {
-		return ""
+// errorFromBody 将DefaultEncodeErrorFunc返回的serialize.Response解析为携带http状态码的errors.Error
+func errorFromBody(response *http.Response, body []byte) errors.Error {
+	status := int32(response.StatusCode)
+	resp := &serialize.Response{}
+	if err := encoding.GetCodec(json.Name).Unmarshal(body, resp); err == nil && resp.Error != nil {
+		return errors.WithStatus(resp.Error, status)
 	}
-	builder := &strings.Builder{}
-	builder.WriteString(pattern)
-	encodeQuery(obj, builder, nil)
 
-	return builder.String()
+	return errors.New(errors.UnknownCode, status, errors.UnknownReason,
+		fmt.Sprintf("unexpected status %d: %s", response.StatusCode, bytes.TrimSpace(body)))
 }
 
-func encodeQuery(obj interface{}, builder *strings.Builder, expect map[string]string) {
-	m := make(map[string]string)
-	// 利用反射从结构体中获取form请求参数
-	reflectGetValues(m, obj, FormKey)
-	// 如果一个字段既添加了param tag 又添加了form tag，则需要忽略form tag，因为已经在param参数中进行了设置
-	if expect != nil {
-		for k := range m {
-			if _, ok := expect[k]; ok {
-				delete(m, k)
-			}
-		}
-	}
-	if len(m) == 0 {
-		return
-	}
-	builder.WriteByte('?')
-	for k, v := range m {
-		if v != "" {
-			builder.WriteString(k + "=" + v)
-		}
+// newRequest 执行CallOption中的before, 并根据Content-Type编码请求体
+func (c *Client) newRequest(ctx context.Context, method, path string, req any, accept string, opts []CallOption) (*http.Request, *BeforeCallInfo, error) {
+	bco := &BeforeCallInfo{
+		Header: make(http.Header),
+		Value:  req,
 	}
-}
-
-func encodeParam(pattern string, obj interface{}, builder *strings.Builder, m map[string]string) {
-	paramPatterns := strings.Split(pattern, "/:")
-	paramPatterns = removeEmptyStringsInPlace(paramPatterns)
-	if len(paramPatterns) == 0 {
-		return
+	for _, opt := range opts {
+		opt.Before(bco)
 	}
 
-	for _, p := range paramPatterns {
-		m[p] = ""
+	contentType := bco.ContentType
+	if contentType == "" {
+		contentType = "application/json"
 	}
 
-	// 利用反射解析出param请求参数
-	reflectGetValues(m, obj, ParamKey)
-	for _, pp := range paramPatterns {
-		if v := m[pp]; v != "" {
-			builder.WriteByte('/')
-			builder.WriteString(v)
+	var bodyReader io.Reader
+	if req != nil {
+		codec := encoding.GetCodecByContentType(contentType)
+		if codec == nil {
+			return nil, nil, fmt.Errorf("unsupported Content-Type: %s", contentType)
 		}
-	}
-}
-
-func removeEmptyStringsInPlace(slice []string) []string {
-	n := 0
-	for _, str := range slice {
-		if str != "" {
-			slice[n] = str
-			n++
+		bodyBytes, err := codec.Marshal(req)
+		if err != nil {
+			return nil, nil, err
 		}
+		bodyReader = bytes.NewReader(bodyBytes)
+	}
+	request, err := http.NewRequestWithContext(ctx, method, c.config.host+path, bodyReader)
+	if err != nil {
+		return nil, nil, err
 	}
-	return slice[:n]
-}
 
-func reflectGetValues(m map[string]string, obj interface{}, tagK string) {
-	rv := reflect.ValueOf(obj)
-	if rv.Kind() == reflect.Ptr && rv.IsNil() {
-		return
+	if bco.ContentType != "" || method != http.MethodGet {
+		request.Header.Set("Content-Type", contentType)
 	}
-	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
-		rv = rv.Elem()
+	request.Header.Set("Accept", accept)
+	for k, v := range bco.Header {
+		request.Header.Set(k, v[0])
 	}
 
-	rt := rv.Type()
-	n := rt.NumField()
-	for i := 0; i < n; i++ {
-		// 获取tag，如果没有则该tag不添加到路径中
-		tagV := rt.Field(i).Tag.Get(tagK)
-		if tagV == "" {
-			continue
-		}
-
-		// 对于param参数，如果该tag没有在路径中声明，则不添加到路径中
-		// 对于form参数，全部添加到路径中
-		if _, ok := m[tagV]; !ok && tagK == ParamKey {
-			continue
-		}
-
-		fiv := rv.Field(i)
-		for fiv.Kind() == reflect.Ptr && !fiv.IsNil() {
-			fiv = fiv.Elem()
-		}
-		if fiv.Kind() == reflect.Ptr && fiv.IsNil() {
-			continue
-		}
-
-		switch fiv.Kind() {
-		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-			m[tagV] = strconv.FormatInt(fiv.Int(), 10)
-		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-			m[tagV] = strconv.FormatUint(fiv.Uint(), 10)
-		case reflect.Float32, reflect.Float64:
-			m[tagV] = strconv.FormatFloat(fiv.Float(), 'g', -1, 64)
-		case reflect.Bool:
-			m[tagV] = strconv.FormatBool(fiv.Bool())
-		case reflect.String:
-			m[tagV] = fiv.String()
-		default:
-		}
-	}
+	return request, bco, nil
 }
@@ -0,0 +1,79 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mangohow/gowlb/errors"
+)
+
+var errUserNotFound = errors.NotFound(404, "USER_NOT_FOUND", "user not found")
+
+type userService interface {
+	GetUser(ctx context.Context, req *echoBody) (*echoBody, error)
+}
+
+type userServer struct{}
+
+func (userServer) GetUser(ctx context.Context, req *echoBody) (*echoBody, error) {
+	if req.Name != "gowlb" {
+		return nil, errors.WithMetadata(errUserNotFound, map[string]string{"name": req.Name})
+	}
+
+	return req, nil
+}
+
+var userServiceDesc = &ServiceDesc{
+	ServiceName: "test.User",
+	HandlerType: (*userService)(nil),
+	Methods: []MethodDesc{
+		{
+			Name:   "GetUser",
+			Method: "GET",
+			Path:   "/user/:name",
+			Handler: func(srv any, ctx context.Context, dec func(any) error, middleware Middleware) (any, error) {
+				in := new(echoBody)
+				if err := dec(in); err != nil {
+					return nil, err
+				}
+				return middleware(ctx, in, func(ctx context.Context, req any) (any, error) {
+					return srv.(userService).GetUser(ctx, req.(*echoBody))
+				})
+			},
+		},
+	},
+}
+
+func TestClientInvokeError(t *testing.T) {
+	s := New()
+	s.RegisterService(userServiceDesc, userServer{})
+	ts := httptest.NewServer(s.HttpServer().Handler)
+	defer ts.Close()
+	c, err := NewClient(WithEndpoint(ts.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reply := &echoBody{}
+	status, err := c.Invoke(context.Background(), http.MethodGet, "/user/gowlb", nil, reply)
+	if err != nil || status != http.StatusOK || reply.Name != "gowlb" {
+		t.Fatalf("invoke = %d, %v, %+v", status, err, reply)
+	}
+
+	status, err = c.Invoke(context.Background(), http.MethodGet, "/user/nobody", nil, &echoBody{})
+	if status != http.StatusNotFound || !errors.Is(err, errUserNotFound) {
+		t.Fatalf("invoke = %d, %v, want USER_NOT_FOUND", status, err)
+	}
+	e, _ := errors.AsError(err)
+	if e.HttpStatus() != http.StatusNotFound || e.Message() != "user not found" || e.Metadata()["name"] != "nobody" {
+		t.Errorf("error = %v, status = %d", e, e.HttpStatus())
+	}
+
+	// 没有匹配的路由同样由DefaultEncodeErrorFunc返回错误
+	_, err = c.Invoke(context.Background(), http.MethodGet, "/missing", nil, &echoBody{})
+	if e, ok := errors.AsError(err); !ok || e.HttpStatus() != http.StatusNotFound || e.Reason() != ReasonNotFound {
+		t.Errorf("invoke error = %v, want NotFound error with status 404", err)
+	}
+}
@@ -0,0 +1,385 @@
+package http
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zlib"
+	"github.com/klauspost/compress/zstd"
+	"github.com/mangohow/gowlb/errors"
+)
+
+const (
+	EncodingGzip    = "gzip"
+	EncodingDeflate = "deflate"
+	EncodingZstd    = "zstd"
+
+	ReasonUnsupportedContentEncoding = "UnsupportedContentEncoding"
+	ReasonInvalidContentEncoding     = "InvalidContentEncoding"
+	ReasonRequestEntityTooLarge      = "RequestEntityTooLarge"
+
+	defaultMaxDecompressedSize = 32 << 20
+)
+
+// Compression 响应压缩配置
+type Compression struct {
+	// 支持的压缩算法, 按照优先级从高到低排列, 默认为 zstd、gzip、deflate
+	// 客户端的Accept-Encoding中q值相同时使用优先级高的算法
+	Encodings []string
+	// 响应体小于MinSize字节时不压缩, 默认为1024
+	MinSize int
+	// 允许压缩的Content-Type, 支持 text/* 形式的通配,
+	// 默认为 application/json、application/xml、application/javascript、application/x-ndjson、text/*
+	ContentTypes []string
+	// 解压后请求体的最大字节数, 超过时返回413, 默认为32MB, 小于0时不限制
+	MaxDecompressedSize int64
+}
+
+// WithCompression 根据Accept-Encoding压缩响应体, 同时解压Content-Encoding为gzip、deflate、zstd的请求体
+// 请求体使用其它编码时返回415, WebSocket请求和已经设置了Content-Encoding的响应不会被压缩
+func WithCompression(c Compression) Option {
+	return func(s *Server) {
+		if len(c.Encodings) == 0 {
+			c.Encodings = []string{EncodingZstd, EncodingGzip, EncodingDeflate}
+		}
+		if c.MinSize == 0 {
+			c.MinSize = 1024
+		}
+		if c.MaxDecompressedSize == 0 {
+			c.MaxDecompressedSize = defaultMaxDecompressedSize
+		}
+		if len(c.ContentTypes) == 0 {
+			c.ContentTypes = []string{"application/json", "application/xml", "application/javascript", "application/x-ndjson", "text/*"}
+		}
+		s.compression = &c
+	}
+}
+
+// negotiate 根据Accept-Encoding选择压缩算法, 没有可用的算法时返回空字符串
+func (c *Compression) negotiate(acceptEncoding string) string {
+	if acceptEncoding == "" {
+		return ""
+	}
+
+	accepted := make(map[string]float64)
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if params = strings.TrimSpace(params); strings.HasPrefix(params, "q=") {
+			if f, err := strconv.ParseFloat(params[2:], 64); err == nil {
+				q = f
+			}
+		}
+		accepted[strings.ToLower(strings.TrimSpace(name))] = q
+	}
+
+	var (
+		best  string
+		bestQ float64
+	)
+	for _, encoding := range c.Encodings {
+		q, ok := accepted[encoding]
+		if !ok {
+			q = accepted["*"]
+		}
+		if q > bestQ {
+			best, bestQ = encoding, q
+		}
+	}
+
+	return best
+}
+
+// compressible 判断Content-Type是否允许压缩
+func (c *Compression) compressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, t := range c.ContentTypes {
+		if matchMediaType(t, mediaType) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// newWriter 返回压缩响应体的ResponseWriter, 处理完成后需要调用close
+// 客户端不支持压缩或者是WebSocket请求时返回nil
+func (c *Compression) newWriter(w http.ResponseWriter, req *http.Request) *compressWriter {
+	w.Header().Add("Vary", "Accept-Encoding")
+	if req.Method == http.MethodHead || req.Header.Get("Upgrade") != "" {
+		return nil
+	}
+	encoding := c.negotiate(req.Header.Get("Accept-Encoding"))
+	if encoding == "" {
+		return nil
+	}
+
+	return &compressWriter{ResponseWriter: w, c: c, encoding: encoding}
+}
+
+// decompress 替换请求体为解压后的数据, 并删除Content-Encoding和Content-Length
+// 解压后的数据超过MaxDecompressedSize时读取请求体返回413错误
+func (c *Compression) decompress(req *http.Request) error {
+	encoding := strings.ToLower(strings.TrimSpace(req.Header.Get("Content-Encoding")))
+	if encoding == "" || encoding == "identity" || req.Body == nil || req.Body == http.NoBody {
+		return nil
+	}
+
+	var (
+		r   io.Reader
+		err error
+	)
+	switch encoding {
+	case EncodingGzip, "x-gzip":
+		r, err = gzip.NewReader(req.Body)
+	case EncodingDeflate:
+		r, err = zlib.NewReader(req.Body)
+	case EncodingZstd:
+		opts := []zstd.DOption{zstd.WithDecoderConcurrency(1)}
+		if c.MaxDecompressedSize > 0 {
+			opts = append(opts, zstd.WithDecoderMaxMemory(uint64(c.MaxDecompressedSize)))
+		}
+		var d *zstd.Decoder
+		if d, err = zstd.NewReader(req.Body, opts...); err == nil {
+			r = d.IOReadCloser()
+		}
+	default:
+		return errors.New(errors.UnknownCode, StatusUnsupportedMediaType, ReasonUnsupportedContentEncoding, "unsupported Content-Encoding: "+encoding)
+	}
+	if err != nil {
+		return errors.BadRequestCause(errors.UnknownCode, ReasonInvalidContentEncoding, fmt.Sprintf("invalid %s request body", encoding), err)
+	}
+
+	body := &decompressReader{Reader: r, decoder: r, body: req.Body}
+	if c.MaxDecompressedSize > 0 {
+		body.Reader = &limitReader{r: r, n: c.MaxDecompressedSize, limit: c.MaxDecompressedSize}
+	}
+	req.Body = body
+	req.Header.Del("Content-Encoding")
+	req.Header.Del("Content-Length")
+	req.ContentLength = -1
+
+	return nil
+}
+
+type decompressReader struct {
+	io.Reader
+	decoder io.Reader
+	body    io.ReadCloser
+}
+
+func (r *decompressReader) Close() error {
+	if c, ok := r.decoder.(io.Closer); ok {
+		c.Close()
+	}
+
+	return r.body.Close()
+}
+
+// limitReader 限制解压后的数据大小, 超过limit时返回413错误
+type limitReader struct {
+	r     io.Reader
+	n     int64
+	limit int64
+	err   error
+}
+
+func (l *limitReader) Read(p []byte) (int, error) {
+	if l.err != nil {
+		return 0, l.err
+	}
+	// 多读取一个字节用于判断是否超过限制
+	if int64(len(p)) > l.n+1 {
+		p = p[:l.n+1]
+	}
+	n, err := l.r.Read(p)
+	if int64(n) > l.n {
+		n, l.n = int(l.n), 0
+		l.err = l.tooLarge()
+		return n, l.err
+	}
+	l.n -= int64(n)
+	if errors.Is(err, zstd.ErrDecoderSizeExceeded) || errors.Is(err, zstd.ErrWindowSizeExceeded) {
+		err = l.tooLarge()
+	}
+	if err != nil && err != io.EOF {
+		l.err = err
+	}
+
+	return n, err
+}
+
+func (l *limitReader) tooLarge() error {
+	return errors.New(errors.UnknownCode, StatusRequestEntityTooLarge, ReasonRequestEntityTooLarge,
+		fmt.Sprintf("decompressed request body exceeds %d bytes", l.limit))
+}
+
+// encoder 压缩算法的写入器, 通过Reset复用
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+var encoderPools = map[string]*sync.Pool{
+	EncodingGzip: {New: func() any {
+		return gzip.NewWriter(nil)
+	}},
+	EncodingDeflate: {New: func() any {
+		return zlib.NewWriter(nil)
+	}},
+	EncodingZstd: {New: func() any {
+		// 浏览器要求zstd的窗口不超过8MB
+		e, _ := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1), zstd.WithWindowSize(1<<20))
+		return e
+	}},
+}
+
+// compressWriter 在响应体达到MinSize或者handler结束时决定是否压缩,
+// 在此之前缓存写入的数据和状态码, 同时保留底层ResponseWriter的Flusher和Hijacker
+type compressWriter struct {
+	http.ResponseWriter
+	c        *Compression
+	encoding string
+
+	status  int
+	buf     []byte
+	decided bool
+	enc     encoder
+	// 已经被Hijack, 不再写入响应
+	hijacked bool
+}
+
+func (w *compressWriter) WriteHeader(status int) {
+	// 1xx状态码直接发送
+	if status < http.StatusOK {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	if !w.decided && w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *compressWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if w.decided {
+		if w.enc != nil {
+			return w.enc.Write(p)
+		}
+		return w.ResponseWriter.Write(p)
+	}
+
+	w.buf = append(w.buf, p...)
+	if len(w.buf) >= w.c.MinSize {
+		if err := w.decide(false); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(p), nil
+}
+
+// decide 写入响应头, 并在满足条件时开始压缩, 之后写入缓存的数据
+// flush为true表示流式响应, 总大小未知, 不检查MinSize
+func (w *compressWriter) decide(flush bool) error {
+	if w.decided {
+		return nil
+	}
+	w.decided = true
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	header := w.Header()
+	if (flush || len(w.buf) >= w.c.MinSize) && header.Get("Content-Encoding") == "" && w.c.compressible(header.Get("Content-Type")) &&
+		w.status != http.StatusNoContent && w.status != http.StatusNotModified {
+		header.Set("Content-Encoding", w.encoding)
+		header.Del("Content-Length")
+		w.enc = encoderPools[w.encoding].Get().(encoder)
+		w.enc.Reset(w.ResponseWriter)
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	var err error
+	if w.enc != nil {
+		_, err = w.enc.Write(buf)
+	} else {
+		_, err = w.ResponseWriter.Write(buf)
+	}
+
+	return err
+}
+
+// Flush 立即发送已经写入的数据, 用于流式响应, 此时不再等待响应体达到MinSize
+func (w *compressWriter) Flush() {
+	if err := w.decide(true); err != nil {
+		return
+	}
+	if w.enc != nil {
+		if err := w.enc.Flush(); err != nil {
+			return
+		}
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("http.Hijacker is not implemented by %T", w.ResponseWriter)
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err == nil {
+		w.hijacked = true
+	}
+
+	return conn, rw, err
+}
+
+// Unwrap 返回底层的ResponseWriter
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// close 写入缓存的数据并结束压缩
+func (w *compressWriter) close() error {
+	if w.hijacked {
+		return nil
+	}
+	if !w.decided && (w.status != 0 || len(w.buf) > 0) {
+		if err := w.decide(false); err != nil {
+			return err
+		}
+	}
+	if w.enc == nil {
+		return nil
+	}
+
+	err := w.enc.Close()
+	w.enc.Reset(nil)
+	encoderPools[w.encoding].Put(w.enc)
+	w.enc = nil
+
+	return err
+}
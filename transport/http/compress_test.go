@@ -0,0 +1,151 @@
+package http
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zlib"
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestCompression(t *testing.T) {
+	s := New(WithCompression(Compression{}))
+	big := strings.Repeat("gowlb", 1000)
+	s.router.GET("/big", func(ctx *Context) error {
+		return ctx.JSON(http.StatusOK, big)
+	})
+	s.router.GET("/small", func(ctx *Context) error {
+		return ctx.JSON(http.StatusOK, "gowlb")
+	})
+	s.router.GET("/stream", func(ctx *Context) error {
+		ctx.WriteContentType("application/x-ndjson")
+		io.WriteString(ctx.ResponseWriter(), "{}\n")
+		ctx.ResponseWriter().(http.Flusher).Flush()
+		return nil
+	})
+	s.router.POST("/echo", func(ctx *Context) error {
+		body := &echoBody{}
+		if err := ctx.BindJSON(body); err != nil {
+			return err
+		}
+		return ctx.JSON(http.StatusOK, body)
+	})
+
+	serve := func(method, path, acceptEncoding string, body io.Reader, contentEncoding string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, body)
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+		if contentEncoding != "" {
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Content-Encoding", contentEncoding)
+		}
+		w := httptest.NewRecorder()
+		s.HttpServer().Handler.ServeHTTP(w, req)
+		return w
+	}
+
+	decoders := map[string]func(r io.Reader) (io.Reader, error){
+		"": func(r io.Reader) (io.Reader, error) { return r, nil },
+		"gzip": func(r io.Reader) (io.Reader, error) {
+			return gzip.NewReader(r)
+		},
+		"deflate": func(r io.Reader) (io.Reader, error) {
+			return zlib.NewReader(r)
+		},
+		"zstd": func(r io.Reader) (io.Reader, error) {
+			return zstd.NewReader(r)
+		},
+	}
+	for _, tt := range []struct {
+		path, accept, want string
+	}{
+		{"/big", "gzip, deflate, br", "gzip"},
+		{"/big", "gzip;q=0.5, zstd", "zstd"},
+		{"/big", "deflate", "deflate"},
+		{"/big", "*", "zstd"},
+		{"/big", "identity", ""},
+		{"/big", "", ""},
+		{"/small", "gzip", ""},
+		{"/stream", "gzip", "gzip"},
+	} {
+		w := serve(http.MethodGet, tt.path, tt.accept, nil, "")
+		if got := w.Header().Get("Content-Encoding"); got != tt.want || w.Header().Get("Vary") != "Accept-Encoding" {
+			t.Errorf("GET %s Accept-Encoding %q = %q, want %q", tt.path, tt.accept, got, tt.want)
+			continue
+		}
+		r, err := decoders[tt.want](w.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(r)
+		if err != nil || (tt.path == "/big" && !strings.Contains(string(data), big)) {
+			t.Errorf("GET %s Accept-Encoding %q body = %d bytes, %v", tt.path, tt.accept, len(data), err)
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	zw := gzip.NewWriter(buf)
+	io.WriteString(zw, `{"name":"gowlb"}`)
+	zw.Close()
+	w := serve(http.MethodPost, "/echo", "", buf, "gzip")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"name":"gowlb"`) {
+		t.Errorf("POST gzip body = %d %s", w.Code, w.Body.String())
+	}
+
+	w = serve(http.MethodPost, "/echo", "", strings.NewReader("{}"), "br")
+	if w.Code != http.StatusUnsupportedMediaType || !strings.Contains(w.Body.String(), ReasonUnsupportedContentEncoding) {
+		t.Errorf("POST br body = %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestDecompressLimit(t *testing.T) {
+	s := New(WithCompression(Compression{MaxDecompressedSize: 1024}))
+	s.router.POST("/echo", func(ctx *Context) error {
+		body := &echoBody{}
+		if err := DefaultDecodeRequestFunc(ctx, &MethodDesc{Method: http.MethodPost, Body: "*"}, body); err != nil {
+			return err
+		}
+		return ctx.JSON(http.StatusOK, body)
+	})
+
+	encoders := map[string]func(w io.Writer) io.WriteCloser{
+		"gzip": func(w io.Writer) io.WriteCloser {
+			return gzip.NewWriter(w)
+		},
+		"deflate": func(w io.Writer) io.WriteCloser {
+			return zlib.NewWriter(w)
+		},
+		"zstd": func(w io.Writer) io.WriteCloser {
+			e, _ := zstd.NewWriter(w)
+			return e
+		},
+	}
+	for encoding, newWriter := range encoders {
+		for _, tt := range []struct {
+			size   int
+			status int
+		}{
+			{1000, http.StatusOK},
+			{1 << 20, http.StatusRequestEntityTooLarge},
+		} {
+			// 高度可压缩的数据, 压缩后远小于限制
+			buf := &bytes.Buffer{}
+			zw := newWriter(buf)
+			io.WriteString(zw, `{"name":"`+strings.Repeat("a", tt.size)+`"}`)
+			zw.Close()
+
+			req := httptest.NewRequest(http.MethodPost, "/echo", buf)
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Content-Encoding", encoding)
+			w := httptest.NewRecorder()
+			s.HttpServer().Handler.ServeHTTP(w, req)
+			if w.Code != tt.status || (tt.status != http.StatusOK && !strings.Contains(w.Body.String(), ReasonRequestEntityTooLarge)) {
+				t.Errorf("POST %s %d bytes = %d %s, want %d", encoding, tt.size, w.Code, w.Body.String(), tt.status)
+			}
+		}
+	}
+}
@@ -2,23 +2,29 @@ package http
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"io"
+	"mime"
 	"net/http"
 	"strings"
 
+	"github.com/mangohow/gowlb/encoding"
+	"github.com/mangohow/gowlb/encoding/json"
 	"github.com/mangohow/gowlb/tools/sync"
 	"github.com/mangohow/gowlb/transport/binding"
 )
 
 var (
-	pool = sync.Pool[*Context]{}
+	pool = sync.NewPool(func() *Context {
+		return &Context{}
+	})
 )
 
 type Context struct {
 	w   http.ResponseWriter
 	req *http.Request
 	s   *Server
+	op  Operation
 }
 
 func newContext(w http.ResponseWriter, r *http.Request, s *Server) *Context {
@@ -34,6 +40,7 @@ func putContext(c *Context) {
 	c.req = nil
 	c.w = nil
 	c.s = nil
+	c.op = Operation{}
 	pool.Put(c)
 }
 
@@ -41,6 +48,11 @@ func (c *Context) Request() *http.Request {
 	return c.req
 }
 
+// Operation 返回当前请求对应的rpc方法
+func (c *Context) Operation() Operation {
+	return c.op
+}
+
 func (c *Context) ResponseWriter() http.ResponseWriter {
 	return c.w
 }
@@ -66,21 +78,28 @@ func (c *Context) BindQuery(obj any) error {
 	return c.s.queryBinding.Bind(c.req, obj)
 }
 
-// 绑定form表单参数 x-www-form-urlencoded
+// 绑定form表单参数 x-www-form-urlencoded 和 multipart/form-data
 func (c *Context) BindForm(obj any) error {
 	contentType := c.req.Header.Get("Content-Type")
-	switch contentType {
-	case "application/x-www-form-urlencoded":
-		return c.BindForm(obj)
+	if contentType == "" {
+		return fmt.Errorf("missing Content-Type header")
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return fmt.Errorf("invalid Content-Type: %s", contentType)
+	}
+
+	switch mediaType {
+	case "application/x-www-form-urlencoded", "multipart/form-data":
+		return c.s.formBinding.Bind(c.req, obj)
 	case "application/json":
 		return c.s.bodyBinding.Bind(c.req, obj)
-	case "":
-		return fmt.Errorf("missing Content-Type header")
 	}
 
-	_, name, found := strings.Cut(contentType, "/")
+	_, name, found := strings.Cut(mediaType, "/")
 	if !found {
-		name = contentType
+		name = mediaType
 	}
 	b := binding.GetBinding(name)
 	if b == nil {
@@ -101,23 +120,76 @@ func (c *Context) BindPathVar(obj any) error {
 }
 
 func (c *Context) String(status int, content string) error {
+	c.w.Header().Set("Content-Type", "text/plain; charset=utf-8")
 	c.w.WriteHeader(status)
-	c.w.Header().Set("Content-Type", "text/plain")
-	_, err := fmt.Fprintf(c.w, content)
+	_, err := io.WriteString(c.w, content)
 
 	return err
 }
 
 func (c *Context) JSON(status int, obj any) error {
+	return c.Encode(status, "application/json; charset=utf-8", encoding.GetCodec(json.Name), obj)
+}
+
+// Encode 使用codec序列化obj并返回
+func (c *Context) Encode(status int, contentType string, codec encoding.Codec, obj any) error {
+	data, err := codec.Marshal(obj)
+	if err != nil {
+		return err
+	}
+
+	c.w.Header().Set("Content-Type", contentType)
 	c.w.WriteHeader(status)
-	c.w.Header().Add("Content-Type", "application/json; charset=utf-8")
-	return json.NewEncoder(c.w).Encode(obj)
+	_, err = c.w.Write(data)
+
+	return err
+}
+
+// Negotiate 根据Accept请求头从offers中选择返回的内容类型
+func (c *Context) Negotiate(offers ...string) string {
+	return negotiateContentType(c.req.Header.Get("Accept"), offers)
+}
+
+// Render 根据Accept请求头从已注册的编解码器中选择合适的格式返回obj, 默认为json
+func (c *Context) Render(status int, obj any) error {
+	offers := []string{encoding.ContentType(json.Name)}
+	for _, name := range encoding.Names() {
+		if name != json.Name {
+			offers = append(offers, encoding.ContentType(name))
+		}
+	}
+
+	contentType := c.Negotiate(offers...)
+	if contentType == offers[0] {
+		return c.JSON(status, obj)
+	}
+
+	return c.Encode(status, contentType, encoding.GetCodecByContentType(contentType), obj)
 }
 
 func (c *Context) WriteStatus(status int) {
 	c.w.WriteHeader(status)
 }
 
+// FromContext 从context中获取Context, 不是由Server处理的请求返回nil
 func FromContext(ctx context.Context) *Context {
-	return ctx.Value(ctxKey).(*Context)
+	c, _ := ctx.Value(ctxKey).(*Context)
+	return c
+}
+
+type operationKey struct{}
+
+// NewOperationContext 将rpc方法写入context, 用于在其它transport(如grpc)中复用依赖Operation的中间件
+func NewOperationContext(ctx context.Context, op Operation) context.Context {
+	return context.WithValue(ctx, operationKey{}, op)
+}
+
+// OperationFromContext 从context中获取当前请求对应的rpc方法
+func OperationFromContext(ctx context.Context) (Operation, bool) {
+	if c := FromContext(ctx); c != nil {
+		return c.op, true
+	}
+
+	op, ok := ctx.Value(operationKey{}).(Operation)
+	return op, ok
 }
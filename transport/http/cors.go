@@ -0,0 +1,123 @@
+package http
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORS 跨域资源共享配置
+type CORS struct {
+	// 允许的来源, 如 https://example.com, 支持通配符, 如 https://*.example.com, * 表示允许所有来源
+	AllowOrigins []string
+	// 使用正则表达式匹配允许的来源, 与AllowOrigins任意一个匹配即允许
+	AllowOriginRegexps []*regexp.Regexp
+	// 预检请求允许的请求头, 为空时允许预检请求中Access-Control-Request-Headers列出的所有请求头
+	AllowHeaders []string
+	// 允许浏览器中的脚本读取的响应头, 如 X-Request-ID
+	ExposeHeaders []string
+	// 是否允许携带cookie等凭证, 此时即使AllowOrigins为 * 也会返回请求中的Origin
+	AllowCredentials bool
+	// 预检请求结果的缓存时间, 为0时不返回Access-Control-Max-Age
+	MaxAge time.Duration
+}
+
+// WithCORS 开启跨域支持, 所有注册的路径都会自动响应OPTIONS预检请求,
+// 允许的请求方法为ServiceDesc中注册到该路径的方法, 预检请求不经过中间件, 避免被鉴权等中间件拒绝
+// 其它请求的Origin被允许时在响应中添加跨域响应头, 包括404和405等错误响应
+func WithCORS(cors CORS) Option {
+	return func(s *Server) {
+		s.cors = &cors
+	}
+}
+
+// allowOrigin 判断origin是否被允许
+func (c *CORS) allowOrigin(origin string) bool {
+	for _, o := range c.AllowOrigins {
+		if o == "*" || o == origin {
+			return true
+		}
+		if prefix, suffix, ok := strings.Cut(o, "*"); ok &&
+			len(origin) > len(prefix)+len(suffix) && strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, suffix) {
+			return true
+		}
+	}
+	for _, re := range c.AllowOriginRegexps {
+		if re.MatchString(origin) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// allowAnyOrigin 是否可以返回 Access-Control-Allow-Origin: *
+func (c *CORS) allowAnyOrigin() bool {
+	if c.AllowCredentials {
+		return false
+	}
+	for _, o := range c.AllowOrigins {
+		if o == "*" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// handle 添加跨域响应头, 并响应预检请求, 返回true表示请求已经处理完成
+// allowedMethods 返回与请求路径匹配的路由注册的请求方法
+func (c *CORS) handle(w http.ResponseWriter, req *http.Request, allowedMethods func(*http.Request) []string) bool {
+	origin := req.Header.Get("Origin")
+	preflight := req.Method == http.MethodOptions && req.Header.Get("Access-Control-Request-Method") != ""
+	header := w.Header()
+	if !c.allowAnyOrigin() {
+		header.Add("Vary", "Origin")
+	}
+	if preflight {
+		header.Add("Vary", "Access-Control-Request-Method")
+		header.Add("Vary", "Access-Control-Request-Headers")
+	}
+	if origin == "" || !c.allowOrigin(origin) {
+		return false
+	}
+
+	// 预检请求的方法不被允许时交给路由处理, 返回404或者405
+	var methods []string
+	if preflight {
+		methods = allowedMethods(req)
+		if !contains(methods, req.Header.Get("Access-Control-Request-Method")) {
+			return false
+		}
+	}
+
+	if c.allowAnyOrigin() {
+		header.Set("Access-Control-Allow-Origin", "*")
+	} else {
+		header.Set("Access-Control-Allow-Origin", origin)
+	}
+	if c.AllowCredentials {
+		header.Set("Access-Control-Allow-Credentials", "true")
+	}
+	if !preflight {
+		if len(c.ExposeHeaders) > 0 {
+			header.Set("Access-Control-Expose-Headers", strings.Join(c.ExposeHeaders, ", "))
+		}
+		return false
+	}
+
+	header.Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+	if len(c.AllowHeaders) > 0 {
+		header.Set("Access-Control-Allow-Headers", strings.Join(c.AllowHeaders, ", "))
+	} else if h := req.Header.Get("Access-Control-Request-Headers"); h != "" {
+		header.Set("Access-Control-Allow-Headers", h)
+	}
+	if c.MaxAge > 0 {
+		header.Set("Access-Control-Max-Age", strconv.Itoa(int(c.MaxAge/time.Second)))
+	}
+	w.WriteHeader(http.StatusNoContent)
+
+	return true
+}
@@ -0,0 +1,65 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestCORS(t *testing.T) {
+	s := New(WithCORS(CORS{
+		AllowOrigins:       []string{"https://app.example.com", "https://*.gowlb.dev"},
+		AllowOriginRegexps: []*regexp.Regexp{regexp.MustCompile(`^http://localhost:\d+$`)},
+		ExposeHeaders:      []string{"X-Request-ID"},
+		AllowCredentials:   true,
+		MaxAge:             time.Hour,
+	}))
+	s.RegisterService(userServiceDesc, userServer{})
+
+	serve := func(method, path, origin, requestMethod string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Origin", origin)
+		if requestMethod != "" {
+			req.Header.Set("Access-Control-Request-Method", requestMethod)
+			req.Header.Set("Access-Control-Request-Headers", "Content-Type")
+		}
+		w := httptest.NewRecorder()
+		s.HttpServer().Handler.ServeHTTP(w, req)
+		return w
+	}
+
+	for _, origin := range []string{"https://app.example.com", "https://api.gowlb.dev", "http://localhost:3000"} {
+		w := serve(http.MethodOptions, "/user/tom", origin, http.MethodGet)
+		h := w.Header()
+		if w.Code != http.StatusNoContent || h.Get("Access-Control-Allow-Origin") != origin ||
+			h.Get("Access-Control-Allow-Methods") != "GET" || h.Get("Access-Control-Allow-Headers") != "Content-Type" ||
+			h.Get("Access-Control-Allow-Credentials") != "true" || h.Get("Access-Control-Max-Age") != "3600" {
+			t.Errorf("preflight from %s = %d %v", origin, w.Code, h)
+		}
+	}
+
+	// 不被允许的来源、方法和路径交给路由处理
+	for _, tt := range []struct {
+		path, origin, method string
+		status               int
+	}{
+		{"/user/tom", "https://evil.com", http.MethodGet, http.StatusMethodNotAllowed},
+		{"/user/tom", "https://gowlb.dev", http.MethodGet, http.StatusMethodNotAllowed},
+		{"/user/tom", "https://app.example.com", http.MethodPost, http.StatusMethodNotAllowed},
+		{"/missing", "https://app.example.com", http.MethodGet, http.StatusNotFound},
+	} {
+		w := serve(http.MethodOptions, tt.path, tt.origin, tt.method)
+		if w.Code != tt.status || w.Header().Get("Access-Control-Allow-Methods") != "" {
+			t.Errorf("preflight %s from %s = %d %v", tt.path, tt.origin, w.Code, w.Header())
+		}
+	}
+
+	w := serve(http.MethodGet, "/user/gowlb", "https://app.example.com", "")
+	h := w.Header()
+	if w.Code != http.StatusOK || h.Get("Access-Control-Allow-Origin") != "https://app.example.com" ||
+		h.Get("Access-Control-Expose-Headers") != "X-Request-ID" || h.Get("Vary") != "Origin" {
+		t.Errorf("GET = %d %v", w.Code, h)
+	}
+}
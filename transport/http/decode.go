@@ -0,0 +1,134 @@
+package http
+
+import (
+	"mime"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/mangohow/gowlb/errors"
+)
+
+const (
+	ReasonBadRequest = "BadRequest"
+)
+
+// DecodeRequestFunc 将请求中的参数解码到v中
+type DecodeRequestFunc func(ctx *Context, desc *MethodDesc, v any) error
+
+// DefaultDecodeRequestFunc 默认的请求解码函数, 遵循 google.api.http 规则:
+// 1. body为"*"时, 请求体解码到整个结构体, 不解析查询参数
+// 2. body为字段名时, 请求体解码到该字段, 其余字段从查询参数中获取
+// 3. 没有body时, 所有字段都从查询参数中获取
+// 4. 路径参数优先级最高, 最后进行绑定
+// 为了兼容之前生成的客户端, 非GET、DELETE请求没有声明body时视为"*"
+func DefaultDecodeRequestFunc(ctx *Context, desc *MethodDesc, v any) error {
+	body := desc.Body
+	if body == "" && hasRequestBody(desc.Method) {
+		body = "*"
+	}
+
+	if body != "*" {
+		if err := ctx.BindQuery(v); err != nil {
+			return decodeError(err)
+		}
+	}
+
+	if body != "" && !isEmptyBody(ctx.req) {
+		if err := bindBody(ctx, body, v); err != nil {
+			return decodeError(err)
+		}
+	}
+
+	if len(mux.Vars(ctx.req)) > 0 {
+		if err := ctx.BindPathVar(v); err != nil {
+			return decodeError(err)
+		}
+	}
+
+	return nil
+}
+
+func decodeError(err error) error {
+	// 读取请求体时返回的错误(如解压后超过大小限制)被binding包装, 需要保留原始的状态码
+	if e, ok := errors.AsError(err); ok {
+		return e
+	}
+
+	return errors.BadRequestCause(errors.UnknownCode, ReasonBadRequest, err.Error(), err)
+}
+
+func hasRequestBody(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodDelete, http.MethodHead, http.MethodOptions:
+		return false
+	}
+
+	return true
+}
+
+func isEmptyBody(r *http.Request) bool {
+	return r.Body == nil || r.Body == http.NoBody || r.ContentLength == 0
+}
+
+// bindBody 根据Content-Type将请求体绑定到v或v中的字段
+func bindBody(ctx *Context, body string, v any) error {
+	target := v
+	if body != "*" {
+		field, err := selectField(v, body)
+		if err != nil {
+			return err
+		}
+		target = field
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(ctx.GetContentType())
+	switch mediaType {
+	case "application/x-www-form-urlencoded", "multipart/form-data":
+		return ctx.s.formBinding.Bind(ctx.req, target)
+	}
+
+	return ctx.s.bodyBinding.Bind(ctx.req, target)
+}
+
+// selectField 根据body中声明的字段名找到结构体中对应字段的指针
+// 字段名与protobuf tag中的name或json tag匹配
+func selectField(v any, name string) (any, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return nil, errors.InternalServer(errors.UnknownCode, errors.UnknownReason, "body selector requires a pointer of struct")
+	}
+	rv = rv.Elem()
+	rt := rv.Type()
+
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if !matchFieldName(field, name) {
+			continue
+		}
+
+		fv := rv.Field(i)
+		if fv.Kind() == reflect.Ptr {
+			if fv.IsNil() {
+				fv.Set(reflect.New(fv.Type().Elem()))
+			}
+			return fv.Interface(), nil
+		}
+
+		return fv.Addr().Interface(), nil
+	}
+
+	return nil, errors.InternalServer(errors.UnknownCode, errors.UnknownReason, "body field "+name+" not found")
+}
+
+func matchFieldName(field reflect.StructField, name string) bool {
+	for _, kv := range strings.Split(field.Tag.Get("protobuf"), ",") {
+		if strings.HasPrefix(kv, "name=") && kv[len("name="):] == name {
+			return true
+		}
+	}
+
+	jsonName, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	return jsonName == name || field.Name == name
+}
@@ -0,0 +1,115 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/mangohow/gowlb/errors"
+)
+
+type decodeUser struct {
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+}
+
+type decodeRequest struct {
+	Id     int64       `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Filter string      `protobuf:"bytes,2,opt,name=filter,proto3" json:"filter,omitempty"`
+	User   *decodeUser `protobuf:"bytes,3,opt,name=user,proto3" json:"user,omitempty"`
+}
+
+func TestDefaultDecodeRequestFunc(t *testing.T) {
+	s := New()
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		vars   map[string]string
+		field  string
+		want   decodeRequest
+	}{
+		{
+			name:   "body * ignores query, path wins over body",
+			method: http.MethodPost, target: "/users/7?filter=query",
+			body:  `{"id":1,"filter":"body","user":{"name":"tom"}}`,
+			vars:  map[string]string{"id": "7"},
+			field: "*",
+			want:  decodeRequest{Id: 7, Filter: "body", User: &decodeUser{Name: "tom"}},
+		},
+		{
+			name:   "body field binds to the field, rest from query",
+			method: http.MethodPatch, target: "/users/7?filter=query&id=3",
+			body:  `{"name":"tom"}`,
+			vars:  map[string]string{"id": "7"},
+			field: "user",
+			want:  decodeRequest{Id: 7, Filter: "query", User: &decodeUser{Name: "tom"}},
+		},
+		{
+			name:   "GET binds query, path wins over query",
+			method: http.MethodGet, target: "/users/7?filter=query&id=3",
+			vars: map[string]string{"id": "7"},
+			want: decodeRequest{Id: 7, Filter: "query"},
+		},
+		{
+			name:   "GET without path variables",
+			method: http.MethodGet, target: "/users?filter=query&id=3&user.name=tom",
+			want: decodeRequest{Id: 3, Filter: "query", User: &decodeUser{Name: "tom"}},
+		},
+		{
+			name:   "POST without body declaration is treated as *",
+			method: http.MethodPost, target: "/users?filter=query",
+			body: `{"id":5}`,
+			want: decodeRequest{Id: 5},
+		},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		if tt.vars != nil {
+			req = mux.SetURLVars(req, tt.vars)
+		}
+		c := newContext(httptest.NewRecorder(), req, s)
+
+		got := decodeRequest{}
+		err := DefaultDecodeRequestFunc(c, &MethodDesc{Method: tt.method, Body: tt.field}, &got)
+		putContext(c)
+		if err != nil {
+			t.Errorf("%s: error = %v", tt.name, err)
+			continue
+		}
+		if got.Id != tt.want.Id || got.Filter != tt.want.Filter || (got.User == nil) != (tt.want.User == nil) ||
+			(got.User != nil && *got.User != *tt.want.User) {
+			t.Errorf("%s: got %+v (user %+v), want %+v", tt.name, got, got.User, tt.want)
+		}
+	}
+}
+
+func TestDecodeBodyFieldNotFound(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	c := newContext(httptest.NewRecorder(), req, New())
+	defer putContext(c)
+
+	err := DefaultDecodeRequestFunc(c, &MethodDesc{Method: http.MethodPost, Body: "missing"}, &decodeRequest{})
+	e, ok := errors.AsError(err)
+	if !ok || e.Code() != errors.UnknownCode || e.HttpStatus() != http.StatusInternalServerError {
+		t.Errorf("error = %v, want internal error with unknown code", err)
+	}
+}
+
+func TestDecodeMalformedBody(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"id":`))
+	req.Header.Set("Content-Type", "application/json")
+	c := newContext(httptest.NewRecorder(), req, New())
+	defer putContext(c)
+
+	err := DefaultDecodeRequestFunc(c, &MethodDesc{Method: http.MethodPost, Body: "*"}, &decodeRequest{})
+	e, ok := errors.AsError(err)
+	if !ok || e.Code() != errors.UnknownCode || e.HttpStatus() != http.StatusBadRequest || e.Reason() != ReasonBadRequest {
+		t.Errorf("error = %v, want bad request with unknown code", err)
+	}
+}
@@ -0,0 +1,209 @@
+package http
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/mangohow/gowlb/transport/binding"
+)
+
+// EncodeURL 根据路由模板生成请求路径, 模板中的 :name 由obj中对应的字段替换, 字段不存在或者为空时返回错误
+// 路径参数按照 param tag、json tag、protobuf tag中的name、字段名 的顺序匹配字段, 嵌套字段使用 . 分隔, 如 :user.id
+// query为true时, 路径参数以外的字段编码为查询参数, 编码规则见EncodeURLFromForm
+func EncodeURL(pattern string, obj interface{}, query bool) (string, error) {
+	if pattern != "/" {
+		pattern = strings.TrimSuffix(pattern, "/")
+	}
+
+	rv := indirectValue(reflect.ValueOf(obj))
+	used := make(map[string]struct{})
+	segments := strings.Split(pattern, "/")
+	for i, seg := range segments {
+		if !strings.HasPrefix(seg, ":") {
+			continue
+		}
+
+		name := seg[1:]
+		value, index, ok := lookupField(rv, name)
+		if !ok {
+			return "", fmt.Errorf("encode url %s: path variable %s not found in %T", pattern, name, obj)
+		}
+		s, ok := formatValue(value)
+		if !ok || s == "" {
+			return "", fmt.Errorf("encode url %s: path variable %s is missing", pattern, name)
+		}
+		segments[i] = url.PathEscape(s)
+		used[index] = struct{}{}
+	}
+
+	path := strings.Join(segments, "/")
+	if !query || !rv.IsValid() {
+		return path, nil
+	}
+
+	values := make(url.Values)
+	encodeValues(values, rv, "", "", used)
+	if len(values) > 0 {
+		path += "?" + values.Encode()
+	}
+
+	return path, nil
+}
+
+// EncodeURLFromForm 将obj中的字段编码为查询参数追加到pattern之后, 参数按照名称排序
+// 参数名按照 form tag、json tag、protobuf tag中的name、字段名 的顺序获取, 与grpc-gateway相同:
+// 嵌套的结构体使用 . 连接字段名, 如 page.size=10; 切片重复参数名, 如 ids=1&ids=2;
+// 值为零值的字段和nil指针被忽略, 指针(proto3 optional)指向的零值会被编码
+func EncodeURLFromForm(pattern string, obj interface{}) (string, error) {
+	rv := indirectValue(reflect.ValueOf(obj))
+	if !rv.IsValid() {
+		return pattern, nil
+	}
+
+	values := make(url.Values)
+	encodeValues(values, rv, "", "", nil)
+	if len(values) == 0 {
+		return pattern, nil
+	}
+
+	return pattern + "?" + values.Encode(), nil
+}
+
+func indirectValue(rv reflect.Value) reflect.Value {
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return reflect.Value{}
+		}
+		rv = rv.Elem()
+	}
+
+	return rv
+}
+
+// lookupField 根据路径参数名查找字段, 返回字段的值和字段的下标路径
+func lookupField(rv reflect.Value, name string) (reflect.Value, string, bool) {
+	var index []string
+	for _, part := range strings.Split(name, ".") {
+		if rv.Kind() != reflect.Struct {
+			return reflect.Value{}, "", false
+		}
+
+		found := false
+		rt := rv.Type()
+		for i := 0; i < rt.NumField(); i++ {
+			if rt.Field(i).IsExported() && binding.FieldKey(rt.Field(i), ParamKey) == part {
+				rv = rv.Field(i)
+				index = append(index, strconv.Itoa(i))
+				found = true
+				break
+			}
+		}
+		if !found {
+			return reflect.Value{}, "", false
+		}
+		if next := indirectValue(rv); next.IsValid() && next.Kind() == reflect.Struct {
+			rv = next
+		}
+	}
+
+	return rv, strings.Join(index, "."), true
+}
+
+// encodeValues 将结构体中的字段编码为查询参数, used中的字段已经作为路径参数, 不再编码
+func encodeValues(values url.Values, rv reflect.Value, prefix, index string, used map[string]struct{}) {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		idx := index + strconv.Itoa(i)
+		if _, ok := used[idx]; ok {
+			continue
+		}
+
+		fv := rv.Field(i)
+		// oneof字段, 使用被设置的字段名
+		if fv.Kind() == reflect.Interface {
+			wrapper := indirectValue(fv)
+			if !wrapper.IsValid() || wrapper.Kind() != reflect.Struct || wrapper.NumField() != 1 {
+				continue
+			}
+			field, fv = wrapper.Type().Field(0), wrapper.Field(0)
+		}
+
+		key := binding.FieldKey(field, FormKey)
+		if key == "" {
+			continue
+		}
+		encodeValue(values, prefix+key, fv, idx, used)
+	}
+}
+
+func encodeValue(values url.Values, key string, v reflect.Value, index string, used map[string]struct{}) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return
+		}
+		if v.Elem().Kind() == reflect.Struct {
+			encodeValues(values, v.Elem(), key+".", index+".", used)
+		} else if s, ok := formatValue(v.Elem()); ok {
+			values.Add(key, s)
+		}
+	case reflect.Struct:
+		encodeValues(values, v, key+".", index+".", used)
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			if s, ok := formatValue(v); ok && s != "" {
+				values.Add(key, s)
+			}
+			return
+		}
+		for i := 0; i < v.Len(); i++ {
+			if s, ok := formatValue(v.Index(i)); ok {
+				values.Add(key, s)
+			}
+		}
+	default:
+		if v.IsZero() {
+			return
+		}
+		if s, ok := formatValue(v); ok {
+			values.Add(key, s)
+		}
+	}
+}
+
+// formatValue 将基本类型转换为字符串, []byte使用base64编码, 不支持的类型返回false
+func formatValue(v reflect.Value) (string, bool) {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return "", false
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), true
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits()), true
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), true
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return base64.StdEncoding.EncodeToString(v.Bytes()), true
+		}
+	}
+
+	return "", false
+}
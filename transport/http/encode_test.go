@@ -0,0 +1,98 @@
+package http
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mangohow/gowlb/transport/binding"
+)
+
+type page struct {
+	Size  int32  `json:"size,omitempty"`
+	Token string `json:"token,omitempty"`
+}
+
+type isListRequest_Filter interface {
+	isListRequest_Filter()
+}
+
+type ListRequest_Owner struct {
+	Owner string `protobuf:"bytes,6,opt,name=owner,proto3,oneof"`
+}
+
+func (*ListRequest_Owner) isListRequest_Filter() {}
+
+type listRequest struct {
+	state  int
+	Name   string               `json:"name,omitempty"`
+	Id     int64                `param:"id" json:"user_id,omitempty"`
+	Tags   []string             `json:"tags,omitempty"`
+	Page   *page                `json:"page,omitempty"`
+	Limit  *int32               `json:"limit,omitempty"`
+	Skip   string               `json:"-"`
+	Filter isListRequest_Filter `protobuf_oneof:"filter"`
+}
+
+func TestEncodeURL(t *testing.T) {
+	limit := int32(0)
+	req := &listRequest{
+		state:  1,
+		Name:   "a b/c",
+		Id:     12,
+		Tags:   []string{"x", "y&z"},
+		Page:   &page{Size: 10},
+		Limit:  &limit,
+		Skip:   "skip",
+		Filter: &ListRequest_Owner{Owner: "tom"},
+	}
+
+	tests := []struct {
+		pattern string
+		query   bool
+		want    string
+	}{
+		{"/users/:id/", false, "/users/12"},
+		{"/users/:id/:name", false, "/users/12/a%20b%2Fc"},
+		{"/users/:id", true, "/users/12?limit=0&name=a+b%2Fc&owner=tom&page.size=10&tags=x&tags=y%26z"},
+		{"/users/:name", true, "/users/a%20b%2Fc?limit=0&owner=tom&page.size=10&tags=x&tags=y%26z&user_id=12"},
+		{"/users/:page.size", false, "/users/10"},
+	}
+	for _, tt := range tests {
+		got, err := EncodeURL(tt.pattern, req, tt.query)
+		if err != nil || got != tt.want {
+			t.Errorf("EncodeURL(%s) = %s, %v, want %s", tt.pattern, got, err, tt.want)
+		}
+	}
+
+	for _, pattern := range []string{"/users/:unknown", "/users/:page.token", "/users/:page"} {
+		if got, err := EncodeURL(pattern, req, false); err == nil {
+			t.Errorf("EncodeURL(%s) = %s, want error", pattern, got)
+		}
+	}
+	if _, err := EncodeURL("/users/:id", nil, false); err == nil {
+		t.Error("EncodeURL with nil obj should fail")
+	}
+
+	got, err := EncodeURLFromForm("/users", &listRequest{Page: &page{Token: "t"}})
+	if err != nil || got != "/users?page.token=t" {
+		t.Errorf("EncodeURLFromForm() = %s, %v", got, err)
+	}
+}
+
+func TestEncodeURLRoundTrip(t *testing.T) {
+	limit := int32(5)
+	in := &listRequest{Name: "tom & jerry", Tags: []string{"a", "b"}, Page: &page{Size: 20, Token: "next"}, Limit: &limit}
+	path, err := EncodeURL("/users", in, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := &listRequest{}
+	if err := (binding.QueryBinding{Tag: "json"}).Bind(httptest.NewRequest("GET", path, nil), out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Name != in.Name || len(out.Tags) != 2 || out.Tags[1] != "b" || out.Page == nil ||
+		*out.Page != *in.Page || out.Limit == nil || *out.Limit != 5 {
+		t.Errorf("bind %s = %+v", path, out)
+	}
+}
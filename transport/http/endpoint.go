@@ -0,0 +1,126 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/mangohow/gowlb/balancer"
+	"github.com/mangohow/gowlb/resolver"
+)
+
+// WithResolver 通过resolver获取服务实例列表, 每次请求(包括重试)由balancer选择一个实例
+// 设置了resolver时可以不设置WithEndpoint, 默认使用http协议, 设置了TLS选项时使用https
+// 实例地址中没有协议时沿用WithEndpoint中的协议, WithEndpoint中的host作为请求的Host头
+func WithResolver(r resolver.Resolver) ClientOption {
+	return func(c *config) {
+		c.resolver = r
+	}
+}
+
+// WithBalancer 设置负载均衡算法, 默认为轮询, 只在设置了WithResolver时生效
+func WithBalancer(b balancer.Balancer) ClientOption {
+	return func(c *config) {
+		c.balancer = b
+	}
+}
+
+// WithEjection 连续失败failures次的实例在duration内不会被选择, 默认连续失败5次剔除30s
+// 网络错误和5xx视为失败, failures为0时不剔除实例
+func WithEjection(failures int, duration time.Duration) ClientOption {
+	return func(c *config) {
+		c.poolOptions = append(c.poolOptions, balancer.WithEjection(failures, duration))
+	}
+}
+
+// watchEndpoints 获取初始的实例列表, 并在后台监听实例列表的变化
+func (c *Client) watchEndpoints() error {
+	b := c.config.balancer
+	if b == nil {
+		b = balancer.RoundRobin()
+	}
+	c.pool = balancer.NewPool(b, c.config.poolOptions...)
+
+	watcher, err := c.config.resolver.Watch(context.Background())
+	if err != nil {
+		return err
+	}
+	addrs, err := watcher.Next()
+	if err != nil {
+		watcher.Stop()
+		return err
+	}
+	c.pool.Update(addrs)
+	c.watcher = watcher
+	c.closed = make(chan struct{})
+
+	go func() {
+		for {
+			addrs, err := watcher.Next()
+			if err == nil {
+				c.pool.Update(addrs)
+				continue
+			}
+
+			// 获取失败时保留当前的实例列表
+			select {
+			case <-c.closed:
+				return
+			case <-time.After(time.Second):
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Close 停止监听服务实例列表的变化
+func (c *Client) Close() error {
+	if c.watcher == nil {
+		return nil
+	}
+	c.closeOnce.Do(func() {
+		close(c.closed)
+	})
+
+	return c.watcher.Stop()
+}
+
+// pick 选择服务实例并修改请求地址, 返回实例地址
+// 没有设置resolver时直接使用WithEndpoint中的地址
+func (c *Client) pick(u *url.URL) (string, balancer.DoneFunc, error) {
+	if c.pool == nil {
+		return u.Host, func(error) {}, nil
+	}
+
+	node, done, err := c.pool.Pick()
+	if err != nil {
+		return "", nil, err
+	}
+
+	addr := node.Address()
+	if i := strings.Index(addr, "://"); i != -1 {
+		u.Scheme, u.Host = addr[:i], addr[i+3:]
+	} else {
+		u.Host = addr
+	}
+
+	return addr, done, nil
+}
+
+// endpointError 网络错误和5xx视为实例故障, 其它错误不影响实例的剔除
+func endpointError(response *http.Response, err error) error {
+	if response != nil {
+		if response.StatusCode >= 500 {
+			return err
+		}
+		return nil
+	}
+	if err != nil && isNetworkError(err) {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mangohow/gowlb/balancer"
+	"github.com/mangohow/gowlb/resolver"
+)
+
+func TestClientResolver(t *testing.T) {
+	hits := make(map[string]int)
+	newServer := func(name string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			hits[name]++
+			w.Header().Set("Content-Type", "application/json")
+			io.WriteString(w, `{"data":{"name":"`+name+`"},"error":null}`)
+		}))
+	}
+	a, b := newServer("a"), newServer("b")
+	defer a.Close()
+	defer b.Close()
+
+	// 没有服务监听的地址, 连续失败后被剔除
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	down := l.Addr().String()
+	l.Close()
+
+	var endpoints []string
+	client, err := NewClient(
+		WithResolver(resolver.Static(a.URL, strings.TrimPrefix(b.URL, "http://"), down)),
+		WithBalancer(balancer.RoundRobin()),
+		WithEjection(1, time.Minute),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond, RetryNetworkErrors: true}),
+		WithInterceptors(func(ctx context.Context, req any, invoker Invoker) (any, error) {
+			info, _ := CallInfoFromContext(ctx)
+			endpoints = append(endpoints, info.Endpoint)
+			return invoker(ctx, req)
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	for i := 0; i < 6; i++ {
+		if _, err := client.Invoke(context.Background(), http.MethodGet, "/", nil, &echoBody{}); err != nil {
+			t.Fatalf("invoke %d: %v, endpoints %v", i, err, endpoints)
+		}
+	}
+	if hits["a"] == 0 || hits["b"] == 0 || hits["a"]+hits["b"] != 6 {
+		t.Errorf("hits = %v, want requests spread across a and b", hits)
+	}
+
+	var failed int
+	for _, endpoint := range endpoints {
+		if endpoint == down {
+			failed++
+		}
+	}
+	if failed != 1 {
+		t.Errorf("endpoints = %v, want %s picked once", endpoints, down)
+	}
+}
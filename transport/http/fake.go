@@ -0,0 +1,65 @@
+package http
+
+import (
+	"sync"
+
+	"github.com/mangohow/gowlb/errors"
+)
+
+// ErrFakeNotImplemented 生成的Fake客户端中没有设置实现的方法返回该错误
+var ErrFakeNotImplemented = errors.New(errors.UnknownCode, StatusNotImplemented, "FakeNotImplemented", "method is not implemented by fake client")
+
+// FakeCall 记录Fake客户端收到的一次调用
+type FakeCall struct {
+	// 客户端接口中的方法名, 如 SayHello
+	Method string
+	// rpc方法全名, 如 /helloworld.v1.Greeter/SayHello
+	Operation string
+	// 请求参数, 方法没有请求参数时为nil
+	Request any
+}
+
+// FakeRecorder 被嵌入到protoc-gen-go-http生成的Fake客户端中, 按照调用顺序记录所有调用
+type FakeRecorder struct {
+	mu    sync.Mutex
+	calls []FakeCall
+}
+
+// RecordCall 记录一次调用, 由生成的代码调用
+func (r *FakeRecorder) RecordCall(method, operation string, req any) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.calls = append(r.calls, FakeCall{Method: method, Operation: operation, Request: req})
+}
+
+// Calls 返回所有调用
+func (r *FakeRecorder) Calls() []FakeCall {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]FakeCall(nil), r.calls...)
+}
+
+// CallsTo 返回对method的所有调用
+func (r *FakeRecorder) CallsTo(method string) []FakeCall {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var calls []FakeCall
+	for _, call := range r.calls {
+		if call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+
+	return calls
+}
+
+// ResetCalls 清空已经记录的调用
+func (r *FakeRecorder) ResetCalls() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.calls = nil
+}
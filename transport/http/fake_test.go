@@ -0,0 +1,19 @@
+package http
+
+import "testing"
+
+func TestFakeRecorder(t *testing.T) {
+	var r FakeRecorder
+	r.RecordCall("Hello", "/test.Greeter/Hello", &echoBody{Name: "tom"})
+	r.RecordCall("Bye", "/test.Greeter/Bye", nil)
+	r.RecordCall("Hello", "/test.Greeter/Hello", &echoBody{Name: "jerry"})
+
+	calls := r.CallsTo("Hello")
+	if len(r.Calls()) != 3 || len(calls) != 2 || calls[1].Request.(*echoBody).Name != "jerry" {
+		t.Errorf("calls = %+v", r.Calls())
+	}
+	r.ResetCalls()
+	if len(r.Calls()) != 0 {
+		t.Errorf("calls after reset = %+v", r.Calls())
+	}
+}
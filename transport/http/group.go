@@ -0,0 +1,70 @@
+package http
+
+import (
+	"strings"
+)
+
+// Group 路由分组, 拥有独立的路径前缀和中间件
+// 分组中的请求依次执行: Server的中间件 -> 父分组的中间件 -> 当前分组的中间件
+type Group struct {
+	s           *Server
+	parent      *Group
+	prefix      string
+	middlewares []Middleware
+}
+
+// Group 创建子分组, 子分组的路径前缀为当前分组的前缀加上prefix
+func (g *Group) Group(prefix string, middlewares ...Middleware) *Group {
+	return &Group{
+		s:           g.s,
+		parent:      g,
+		prefix:      joinPath(g.prefix, prefix),
+		middlewares: middlewares,
+	}
+}
+
+// Middleware 为当前分组添加中间件
+func (g *Group) Middleware(middleware ...Middleware) {
+	g.middlewares = append(g.middlewares, middleware...)
+}
+
+// Prefix 返回分组的完整路径前缀
+func (g *Group) Prefix() string {
+	return g.prefix
+}
+
+func (g *Group) RegisterService(sd *ServiceDesc, srv interface{}) {
+	g.s.checkHandlerType(sd, srv)
+	g.s.register(sd, srv, g.prefix, g.chain)
+}
+
+// chain 返回当前分组生效的全部中间件
+func (g *Group) chain() []Middleware {
+	var middlewares []Middleware
+	if g.parent != nil {
+		middlewares = g.parent.chain()
+	} else {
+		middlewares = append(middlewares, g.s.middlewares...)
+	}
+
+	return append(middlewares, g.middlewares...)
+}
+
+// joinPath 拼接路径前缀和路径, 保证两者之间只有一个 /, 非空的结果总是以 / 开头
+func joinPath(prefix, path string) string {
+	prefix = strings.TrimSuffix(prefix, "/")
+	if prefix != "" && !strings.HasPrefix(prefix, "/") {
+		prefix = "/" + prefix
+	}
+	if path != "" && !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	if prefix == "" {
+		return path
+	}
+	if path == "" || path == "/" {
+		return prefix
+	}
+
+	return prefix + path
+}
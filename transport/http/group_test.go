@@ -0,0 +1,87 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestJoinPath(t *testing.T) {
+	tests := []struct {
+		prefix, path, want string
+	}{
+		{"", "/users", "/users"},
+		{"/", "/users", "/users"},
+		{"/api", "/users", "/api/users"},
+		{"/api/", "/users", "/api/users"},
+		{"/api", "users", "/api/users"},
+		{"api", "/users", "/api/users"},
+		{"", "users", "/users"},
+		{"", "", ""},
+		{"api", "", "/api"},
+		{"api/", "", "/api"},
+		{"/api", "/", "/api"},
+		{"/api", "", "/api"},
+		{"/api/v1", "/users/:id", "/api/v1/users/:id"},
+	}
+	for _, tt := range tests {
+		if got := joinPath(tt.prefix, tt.path); got != tt.want {
+			t.Errorf("joinPath(%q, %q) = %q, want %q", tt.prefix, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestGroup(t *testing.T) {
+	var trace []string
+	record := func(name string) Middleware {
+		return func(ctx context.Context, req any, handler Handler) (any, error) {
+			trace = append(trace, name)
+			return handler(ctx, req)
+		}
+	}
+
+	s := New()
+	s.Middleware(record("server1"))
+	api := s.Group("/api/", record("api"))
+	v1 := api.Group("v1", record("v1-1"))
+	v1.Middleware(record("v1-2"))
+	// 前缀没有以 / 开头
+	v2 := s.Group("v2")
+	api.RegisterService(userServiceDesc, userServer{})
+	v1.RegisterService(userServiceDesc, userServer{})
+	v2.RegisterService(userServiceDesc, userServer{})
+	// 注册服务之后添加的中间件同样生效
+	s.Middleware(record("server2"))
+	v1.Middleware(record("v1-3"), func(ctx context.Context, req any, handler Handler) (any, error) {
+		op, _ := OperationFromContext(ctx)
+		trace = append(trace, op.Pattern)
+		return handler(ctx, req)
+	})
+
+	if api.Prefix() != "/api" || v1.Prefix() != "/api/v1" || v2.Prefix() != "/v2" {
+		t.Errorf("Prefix() = %s, %s, %s", api.Prefix(), v1.Prefix(), v2.Prefix())
+	}
+
+	tests := []struct {
+		path   string
+		status int
+		trace  []string
+	}{
+		{"/api/v1/user/gowlb", http.StatusOK, []string{"server1", "server2", "api", "v1-1", "v1-2", "v1-3", "/api/v1/user/:name"}},
+		{"/api/user/gowlb", http.StatusOK, []string{"server1", "server2", "api"}},
+		{"/v2/user/gowlb", http.StatusOK, []string{"server1", "server2"}},
+		// 未匹配的路由只经过Server的中间件
+		{"/user/gowlb", http.StatusNotFound, []string{"server1", "server2"}},
+		{"/v1/user/gowlb", http.StatusNotFound, []string{"server1", "server2"}},
+	}
+	for _, tt := range tests {
+		trace = nil
+		w := httptest.NewRecorder()
+		s.HttpServer().Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if w.Code != tt.status || !reflect.DeepEqual(trace, tt.trace) {
+			t.Errorf("GET %s = %d, trace %v, want %d, trace %v", tt.path, w.Code, trace, tt.status, tt.trace)
+		}
+	}
+}
@@ -14,6 +14,10 @@ const (
 	StatusForbidden    = http.StatusForbidden
 	StatusNotFound     = http.StatusNotFound
 
+	StatusMethodNotAllowed      = http.StatusMethodNotAllowed
+	StatusRequestEntityTooLarge = http.StatusRequestEntityTooLarge
+	StatusUnsupportedMediaType  = http.StatusUnsupportedMediaType
+
 	StatusInternalServerError = http.StatusInternalServerError
 	StatusNotImplemented      = http.StatusNotImplemented
 	StatusBadGateway          = http.StatusBadGateway
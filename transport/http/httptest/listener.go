@@ -0,0 +1,74 @@
+package httptest
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+)
+
+// ErrListenerClosed listener关闭后Accept和Dial返回该错误
+var ErrListenerClosed = errors.New("httptest: listener closed")
+
+type memAddr struct{}
+
+func (memAddr) Network() string { return "memory" }
+func (memAddr) String() string  { return "memory" }
+
+// Listener 内存中的listener, 通过Dial建立的连接由Accept返回, 不占用端口
+type Listener struct {
+	conns  chan net.Conn
+	closed chan struct{}
+	once   sync.Once
+}
+
+func NewListener() *Listener {
+	return &Listener{
+		conns:  make(chan net.Conn),
+		closed: make(chan struct{}),
+	}
+}
+
+func (l *Listener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.closed:
+		return nil, ErrListenerClosed
+	}
+}
+
+func (l *Listener) Close() error {
+	l.once.Do(func() {
+		close(l.closed)
+	})
+
+	return nil
+}
+
+func (l *Listener) Addr() net.Addr {
+	return memAddr{}
+}
+
+// Dial 建立一个到listener的连接
+func (l *Listener) Dial() (net.Conn, error) {
+	return l.DialContext(context.Background(), "memory", "memory")
+}
+
+// DialContext 与net.Dialer.DialContext的签名相同, 忽略network和addr, 可以直接设置到http.Transport中
+func (l *Listener) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	server, client := net.Pipe()
+	var err error
+	select {
+	case l.conns <- server:
+		return client, nil
+	case <-l.closed:
+		err = ErrListenerClosed
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	server.Close()
+	client.Close()
+
+	return nil, err
+}
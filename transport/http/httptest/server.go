@@ -0,0 +1,72 @@
+package httptest
+
+import (
+	"context"
+	"io"
+	nethttp "net/http"
+	"time"
+
+	"github.com/mangohow/gowlb/transport/http"
+	"github.com/sirupsen/logrus"
+)
+
+// Endpoint 内存服务的地址, 只用于生成请求的Host头, 连接总是建立在Listener上
+const Endpoint = "http://gowlb.test"
+
+// Server 运行在内存listener上的http.Server, 用于在测试中调用服务而不占用端口
+type Server struct {
+	// Server 被测试的服务, 可以在测试中继续添加中间件
+	Server *http.Server
+	// Listener 服务使用的内存listener, 可以通过Listener.DialContext建立自定义的连接
+	Listener *Listener
+
+	errCh chan error
+}
+
+// NewServer 使用内存listener创建服务并在后台启动, register用于注册服务和中间件
+// 默认不输出服务日志, opts中的WithAddr和WithListener会被忽略
+func NewServer(register func(s *http.Server), opts ...http.Option) *Server {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	ln := NewListener()
+	opts = append([]http.Option{http.WithLogger(logger)}, opts...)
+	s := &Server{
+		Server:   http.New(append(opts, http.WithListener(ln))...),
+		Listener: ln,
+		errCh:    make(chan error, 1),
+	}
+	if register != nil {
+		register(s.Server)
+	}
+
+	go func() {
+		s.errCh <- s.Server.Start()
+	}()
+
+	return s
+}
+
+// Client 创建连接到服务的客户端, opts在默认选项之后生效
+func (s *Server) Client(opts ...http.ClientOption) (*http.Client, error) {
+	transport := &nethttp.Transport{
+		DialContext:     s.Listener.DialContext,
+		MaxIdleConns:    10,
+		IdleConnTimeout: 30 * time.Second,
+	}
+	opts = append([]http.ClientOption{http.WithEndpoint(Endpoint), http.WithTransport(transport)}, opts...)
+
+	return http.NewClient(opts...)
+}
+
+// Close 关闭服务并等待服务退出, 返回服务运行过程中的错误
+func (s *Server) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := s.Server.Stop(ctx); err != nil {
+		return err
+	}
+
+	return <-s.errCh
+}
@@ -0,0 +1,69 @@
+package httptest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mangohow/gowlb/transport/http"
+)
+
+type greeting struct {
+	Name string `json:"name" param:"name"`
+}
+
+type greeter interface {
+	Hello(ctx context.Context, req *greeting) (*greeting, error)
+}
+
+type greeterServer struct{}
+
+func (greeterServer) Hello(ctx context.Context, req *greeting) (*greeting, error) {
+	return &greeting{Name: "hello " + req.Name}, nil
+}
+
+var greeterServiceDesc = &http.ServiceDesc{
+	ServiceName: "test.Greeter",
+	HandlerType: (*greeter)(nil),
+	Methods: []http.MethodDesc{
+		{
+			Name:   "Hello",
+			Method: "GET",
+			Path:   "/hello/:name",
+			Handler: func(srv any, ctx context.Context, dec func(any) error, middleware http.Middleware) (any, error) {
+				in := new(greeting)
+				if err := dec(in); err != nil {
+					return nil, err
+				}
+				return middleware(ctx, in, func(ctx context.Context, req any) (any, error) {
+					return srv.(greeter).Hello(ctx, req.(*greeting))
+				})
+			},
+		},
+	},
+}
+
+func TestServer(t *testing.T) {
+	s := NewServer(func(s *http.Server) {
+		s.RegisterService(greeterServiceDesc, greeterServer{})
+	})
+
+	client, err := s.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"tom", "jerry"} {
+		reply := &greeting{}
+		status, err := client.Invoke(context.Background(), "GET", "/hello/"+name, nil, reply)
+		if err != nil || status != http.StatusOK || reply.Name != "hello "+name {
+			t.Fatalf("Invoke(%s) = %d, %v, %+v", name, status, err, reply)
+		}
+	}
+
+	if err := s.Close(); err != nil {
+		t.Errorf("Close() = %v", err)
+	}
+	if _, err := client.Invoke(context.Background(), "GET", "/hello/tom", nil, &greeting{}); err == nil {
+		t.Error("Invoke() after Close should fail")
+	}
+}
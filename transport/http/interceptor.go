@@ -0,0 +1,66 @@
+package http
+
+import (
+	"context"
+	"net/http"
+)
+
+// Invoker 发送请求并将响应解码到reply中, req为请求参数
+type Invoker func(ctx context.Context, req any) (reply any, err error)
+
+// Interceptor 客户端拦截器, 与服务端Middleware的形式相同, 在每次尝试(包括重试)时执行
+// 请求体在执行拦截器前已经编码, 修改req不会改变发送的内容, 需要修改请求头时使用CallInfo.Request
+// 流式请求(NewStream、NewWebSocket)只在建立连接时执行一次拦截器, 不会重试,
+// 连接在拦截器返回后仍然使用, 因此握手请求使用调用方传入的ctx发送, 拦截器中对ctx的修改不会生效
+type Interceptor func(ctx context.Context, req any, invoker Invoker) (any, error)
+
+type callInfoKey struct{}
+
+// CallInfo 描述一次客户端请求, 拦截器中通过CallInfoFromContext获取
+type CallInfo struct {
+	// rpc方法全名, 如 /helloworld.v1.Greeter/SayHello, 由生成的客户端设置
+	Operation string
+	// 本次尝试选择的服务实例地址, 没有设置WithResolver时为WithEndpoint中的host
+	Endpoint string
+	// 本次尝试发送的请求, 在调用invoker之前可以修改请求头
+	Request *http.Request
+	// invoker返回后为服务端的响应, 响应体已经被读取, 请求失败时为nil
+	// 流式请求中响应体为消息流, 拦截器不能读取或关闭
+	Response *http.Response
+	// 当前是第几次尝试, 从1开始
+	Attempt int
+}
+
+// CallInfoFromContext 从context中获取当前请求的CallInfo, 只在客户端拦截器中有效
+func CallInfoFromContext(ctx context.Context) (*CallInfo, bool) {
+	info, ok := ctx.Value(callInfoKey{}).(*CallInfo)
+	return info, ok
+}
+
+// intercept 将info放入ctx, 并通过客户端的拦截器执行invoker
+func (c *Client) intercept(ctx context.Context, info *CallInfo, req any, invoker Invoker) error {
+	ctx = context.WithValue(ctx, callInfoKey{}, info)
+	if len(c.config.interceptors) == 0 {
+		_, err := invoker(ctx, req)
+		return err
+	}
+
+	_, err := ChainInterceptors(c.config.interceptors...)(ctx, req, invoker)
+	return err
+}
+
+// ChainInterceptors 将多个拦截器组合为一个, 按照参数顺序执行
+func ChainInterceptors(interceptors ...Interceptor) Interceptor {
+	return func(ctx context.Context, req any, invoker Invoker) (any, error) {
+		return getChainInterceptor(interceptors, -1, invoker)(ctx, req)
+	}
+}
+
+func getChainInterceptor(interceptors []Interceptor, cur int, invoker Invoker) Invoker {
+	if cur == len(interceptors)-1 {
+		return invoker
+	}
+	return func(ctx context.Context, req any) (any, error) {
+		return interceptors[cur+1](ctx, req, getChainInterceptor(interceptors, cur+1, invoker))
+	}
+}
@@ -0,0 +1,121 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/mangohow/gowlb/errors"
+)
+
+func TestClientInterceptors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"data":{"name":"`+r.Header.Get("X-Request-Id")+`"},"error":null}`)
+	}))
+	defer srv.Close()
+
+	var trace []string
+	auth := func(ctx context.Context, req any, invoker Invoker) (any, error) {
+		info, _ := CallInfoFromContext(ctx)
+		trace = append(trace, "auth")
+		// 第一次尝试不携带token, 触发重试
+		if info.Attempt > 1 {
+			info.Request.Header.Set("Authorization", "Bearer token")
+		}
+		return invoker(ctx, req)
+	}
+	requestID := func(ctx context.Context, req any, invoker Invoker) (any, error) {
+		info, _ := CallInfoFromContext(ctx)
+		trace = append(trace, "request-id")
+		info.Request.Header.Set("X-Request-Id", info.Operation)
+		reply, err := invoker(ctx, req)
+		trace = append(trace, info.Response.Status)
+		return reply, err
+	}
+
+	client, err := NewClient(
+		WithEndpoint(srv.URL),
+		WithInterceptors(auth, requestID),
+		WithRetryPolicy(RetryPolicy{InitialBackoff: time.Millisecond, RetryableStatus: []int{http.StatusServiceUnavailable}}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reply := &echoBody{}
+	status, err := client.Invoke(context.Background(), http.MethodGet, "/", nil, reply, OperationCallOption("/test.Echo/Echo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status != http.StatusOK || reply.Name != "/test.Echo/Echo" {
+		t.Fatalf("status=%d reply=%+v", status, reply)
+	}
+
+	want := []string{"auth", "request-id", "503 Service Unavailable", "auth", "request-id", "200 OK"}
+	if len(trace) != len(want) {
+		t.Fatalf("trace=%v", trace)
+	}
+	for i := range want {
+		if trace[i] != want[i] {
+			t.Fatalf("trace=%v", trace)
+		}
+	}
+}
+
+func TestClientInterceptorsStream(t *testing.T) {
+	s := New()
+	s.Middleware(func(ctx context.Context, req any, handler Handler) (any, error) {
+		if FromContext(ctx).Request().Header.Get("Authorization") != "Bearer token" {
+			return nil, errors.New(401, 401, "Unauthorized", "missing token")
+		}
+		return handler(ctx, req)
+	})
+	s.RegisterService(countServiceDesc, countServer{})
+	s.RegisterService(echoServiceDesc, echoServer{})
+	ts := httptest.NewServer(s.HttpServer().Handler)
+	defer ts.Close()
+
+	var trace []string
+	auth := func(ctx context.Context, req any, invoker Invoker) (any, error) {
+		info, _ := CallInfoFromContext(ctx)
+		if info.Operation != "/test.Echo/Echo" {
+			info.Request.Header.Set("Authorization", "Bearer token")
+		}
+		reply, err := invoker(ctx, req)
+		trace = append(trace, info.Operation+" "+info.Response.Status)
+		return reply, err
+	}
+	c, err := NewClient(WithEndpoint(ts.URL), WithInterceptors(auth))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stream, err := c.NewStream(context.Background(), "GET", "/count?n=1", nil, OperationCallOption("/test.Counter/Count"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	reply := &countReply{}
+	if err := stream.RecvMsg(reply); err != nil {
+		t.Errorf("RecvMsg() error = %v", err)
+	}
+	stream.Close()
+
+	// 拦截器没有设置token, 握手失败
+	_, err = c.NewWebSocket(context.Background(), "/echo", OperationCallOption("/test.Echo/Echo"))
+	if e, ok := errors.AsError(err); !ok || e.Reason() != "Unauthorized" {
+		t.Errorf("NewWebSocket() error = %v, want Unauthorized", err)
+	}
+
+	want := []string{"/test.Counter/Count 200 OK", "/test.Echo/Echo 401 Unauthorized"}
+	if len(trace) != len(want) || trace[0] != want[0] || trace[1] != want[1] {
+		t.Errorf("trace = %v, want %v", trace, want)
+	}
+}
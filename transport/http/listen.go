@@ -0,0 +1,43 @@
+package http
+
+import (
+	"net"
+	"os"
+	"strings"
+	"syscall"
+
+	"github.com/mangohow/gowlb/errors"
+)
+
+const unixScheme = "unix://"
+
+// listen 返回服务使用的listener, 优先使用WithListener设置的listener
+// 地址以 unix:// 开头时监听 Unix domain socket, 否则监听TCP地址
+func (s *Server) listen() (net.Listener, error) {
+	if s.listener != nil {
+		return s.listener, nil
+	}
+
+	if !strings.HasPrefix(s.addr, unixScheme) {
+		return net.Listen("tcp", s.addr)
+	}
+
+	path := strings.TrimPrefix(s.addr, unixScheme)
+	// 删除上次退出时残留的socket文件, 避免出现 address already in use
+	// 只有连接被拒绝时才认为文件已经没有进程监听, 否则可能删除正在运行的服务的socket
+	if info, err := os.Stat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+		conn, err := net.Dial("unix", path)
+		if err == nil {
+			_ = conn.Close()
+			return nil, &net.OpError{Op: "listen", Net: "unix", Addr: &net.UnixAddr{Name: path, Net: "unix"},
+				Err: os.NewSyscallError("bind", syscall.EADDRINUSE)}
+		}
+		if errors.Is(err, syscall.ECONNREFUSED) {
+			if err := os.Remove(path); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return net.Listen("unix", path)
+}
@@ -0,0 +1,92 @@
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/mangohow/gowlb/errors"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/http2"
+)
+
+func TestListenUnixSocketInUse(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "app.sock")
+	l, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.(*net.UnixListener).SetUnlinkOnClose(false)
+
+	// 其他进程正在监听时不能删除socket文件
+	s := New(WithAddr("unix://" + sock))
+	if _, err := s.listen(); !errors.Is(err, syscall.EADDRINUSE) {
+		t.Fatalf("listen() error = %v, want address already in use", err)
+	}
+
+	// 残留的socket文件被删除后重新监听
+	_ = l.Close()
+	l, err = s.listen()
+	if err != nil {
+		t.Fatalf("listen() error = %v", err)
+	}
+	_ = l.Close()
+}
+
+func TestUnixSocketH2C(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "app.sock")
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	s := New(WithAddr("unix://"+sock), WithH2C(), WithLogger(logger))
+	s.router.GET("/proto", func(ctx *Context) error {
+		return ctx.String(http.StatusOK, strconv.Itoa(ctx.Request().ProtoMajor))
+	})
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- s.Start()
+	}()
+	defer func() {
+		_ = s.Stop(context.Background())
+		if err := <-errCh; err != nil {
+			t.Errorf("Start() error = %v", err)
+		}
+	}()
+
+	dial := func(ctx context.Context) (net.Conn, error) {
+		var d net.Dialer
+		for {
+			conn, err := d.DialContext(ctx, "unix", sock)
+			if err == nil || ctx.Err() != nil {
+				return conn, err
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	client := &http.Client{
+		Timeout: time.Second,
+		Transport: &http2.Transport{
+			AllowHTTP: true,
+			DialTLSContext: func(ctx context.Context, network, addr string, cfg *tls.Config) (net.Conn, error) {
+				return dial(ctx)
+			},
+		},
+	}
+
+	resp, err := client.Get("http://unix/proto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "2" {
+		t.Errorf("proto major = %s, want 2", body)
+	}
+}
@@ -8,15 +8,43 @@ type Handler func(ctx context.Context, req any) (resp any, err error)
 
 type Middleware func(ctx context.Context, req any, handler Handler) (any, error)
 
-type methodHandler func(ctx context.Context, srv any, middleware Middleware) (any, error)
+// methodHandler 由protoc-gen-go-http生成, dec用于将请求参数解码到请求结构体中
+type methodHandler func(srv any, ctx context.Context, dec func(any) error, middleware Middleware) (any, error)
 
 type ServiceDesc struct {
+	// proto service 全名, 如 helloworld.v1.Greeter
+	ServiceName string
 	HandlerType interface{}
 	Methods     []MethodDesc
+	// 服务端流式rpc方法
+	Streams []StreamDesc
 }
 
 type MethodDesc struct {
-	Method  string
-	Path    string
+	// rpc 方法名
+	Name   string
+	Method string
+	Path   string
+	// google.api.http 中的body, 为"*"时body映射到整个请求结构体, 为字段名时映射到该字段
+	Body    string
 	Handler methodHandler
 }
+
+// Operation 描述当前请求对应的rpc方法
+type Operation struct {
+	Service    string
+	Method     string
+	HTTPMethod string
+	// 注册的路由模板, 包含分组前缀, 如 /api/user/:id
+	Pattern string
+}
+
+// FullName 返回 /{Service}/{Method} 形式的方法全名, 如 /helloworld.v1.Greeter/SayHello
+func (o Operation) FullName() string {
+	return "/" + o.Service + "/" + o.Method
+}
+
+// Chain 将多个中间件组合为一个, 按照参数顺序执行
+func Chain(middlewares ...Middleware) Middleware {
+	return chainHandler(middlewares)
+}
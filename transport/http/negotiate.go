@@ -0,0 +1,82 @@
+package http
+
+import (
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type acceptSpec struct {
+	mediaType string
+	q         float64
+	// 越具体的类型优先级越高: */* < type/* < type/subtype
+	specificity int
+}
+
+// parseAccept 解析Accept请求头, 按照q值和具体程度从高到低排序
+func parseAccept(accept string) []acceptSpec {
+	specs := make([]acceptSpec, 0, 4)
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		if q <= 0 {
+			continue
+		}
+
+		specificity := 2
+		if mediaType == "*/*" {
+			specificity = 0
+		} else if strings.HasSuffix(mediaType, "/*") {
+			specificity = 1
+		}
+		specs = append(specs, acceptSpec{mediaType: mediaType, q: q, specificity: specificity})
+	}
+
+	sort.SliceStable(specs, func(i, j int) bool {
+		if specs[i].q != specs[j].q {
+			return specs[i].q > specs[j].q
+		}
+		return specs[i].specificity > specs[j].specificity
+	})
+
+	return specs
+}
+
+func matchMediaType(spec, offer string) bool {
+	if spec == "*/*" || spec == offer {
+		return true
+	}
+	if strings.HasSuffix(spec, "/*") {
+		return strings.HasPrefix(offer, strings.TrimSuffix(spec, "*"))
+	}
+
+	return false
+}
+
+// negotiateContentType 根据Accept请求头从offers中选择最合适的内容类型
+// Accept为空或没有可接受的类型时返回offers[0]
+func negotiateContentType(accept string, offers []string) string {
+	if len(offers) == 0 {
+		return ""
+	}
+
+	for _, spec := range parseAccept(accept) {
+		for _, offer := range offers {
+			if matchMediaType(spec.mediaType, offer) {
+				return offer
+			}
+		}
+	}
+
+	return offers[0]
+}
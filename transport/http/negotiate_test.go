@@ -0,0 +1,130 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mangohow/gowlb/encoding"
+	"github.com/mangohow/gowlb/errors"
+	"github.com/mangohow/gowlb/serialize"
+)
+
+func TestNegotiateContentType(t *testing.T) {
+	offers := []string{"application/json", "application/yaml", "text/plain"}
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{"", "application/json"},
+		{"*/*", "application/json"},
+		{"application/xml", "application/json"},
+		{"invalid", "application/json"},
+		{"application/yaml", "application/yaml"},
+		{"text/html, text/*;q=0.9", "text/plain"},
+		{"application/json;q=0.5, application/yaml", "application/yaml"},
+		{"*/*, application/yaml", "application/yaml"},
+		{"application/*;q=0.8, text/plain;q=0.9", "text/plain"},
+		{"application/json;q=0, */*;q=0.1", "application/json"},
+		{"application/yaml;q=0, text/plain;q=0.1", "text/plain"},
+	}
+	for _, tt := range tests {
+		if got := negotiateContentType(tt.accept, offers); got != tt.want {
+			t.Errorf("negotiateContentType(%q) = %s, want %s", tt.accept, got, tt.want)
+		}
+	}
+	if got := negotiateContentType("*/*", nil); got != "" {
+		t.Errorf("negotiateContentType without offers = %s", got)
+	}
+}
+
+// renderCodec 用于测试的编解码器, 只输出响应中的数据
+type renderCodec struct{}
+
+func (renderCodec) Marshal(v any) ([]byte, error) {
+	if r, ok := v.(serialize.Response); ok {
+		v = r.Data
+	}
+	return []byte(fmt.Sprintf("data=%+v", v)), nil
+}
+
+func (renderCodec) Unmarshal(data []byte, v any) error {
+	return fmt.Errorf("not supported")
+}
+
+func (renderCodec) Name() string {
+	return "x-render"
+}
+
+func TestRender(t *testing.T) {
+	encoding.RegisterCodec(renderCodec{})
+	s := New()
+
+	tests := []struct {
+		accept      string
+		err         error
+		status      int
+		contentType string
+		body        string
+	}{
+		{
+			accept: "", status: http.StatusOK,
+			contentType: "application/json; charset=utf-8",
+			body:        `{"data":{"name":"tom"},"error":null}`,
+		},
+		{
+			accept: "*/*", status: http.StatusOK,
+			contentType: "application/json; charset=utf-8",
+			body:        `{"data":{"name":"tom"},"error":null}`,
+		},
+		{
+			accept: "application/xml", status: http.StatusOK,
+			contentType: "application/json; charset=utf-8",
+			body:        `{"data":{"name":"tom"},"error":null}`,
+		},
+		{
+			accept: "application/x-render", status: http.StatusOK,
+			contentType: "application/x-render",
+			body:        `data=&{Name:tom}`,
+		},
+		{
+			accept: "application/xml", err: errors.NotFound(404001, "UserNotFound", "user not found"),
+			status:      http.StatusNotFound,
+			contentType: "application/json; charset=utf-8",
+			body:        `{"data":null,"error":{"code":404001,"reason":"UserNotFound","message":"user not found","metadata":null}}`,
+		},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tt.accept != "" {
+			req.Header.Set("Accept", tt.accept)
+		}
+		w := httptest.NewRecorder()
+		c := newContext(w, req, s)
+		if tt.err != nil {
+			DefaultEncodeErrorFunc(c, tt.err)
+		} else {
+			DefaultEncodeResultFunc(c, &echoBody{Name: "tom"})
+		}
+		putContext(c)
+
+		if w.Code != tt.status || w.Header().Get("Content-Type") != tt.contentType || w.Body.String() != tt.body {
+			t.Errorf("Accept %q = %d %s %s, want %d %s %s", tt.accept, w.Code, w.Header().Get("Content-Type"), w.Body.String(),
+				tt.status, tt.contentType, tt.body)
+		}
+	}
+
+	// Encode使用指定的编解码器, 不受Accept影响
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept", "application/x-render")
+	w := httptest.NewRecorder()
+	c := newContext(w, req, s)
+	defer putContext(c)
+	if err := c.Encode(http.StatusCreated, "application/json", encoding.GetCodec("json"), serialize.Response{Data: "ok"}); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusCreated || w.Header().Get("Content-Type") != "application/json" || w.Body.String() != `{"data":"ok","error":null}` {
+		t.Errorf("Encode() = %d %v %s", w.Code, w.Header(), w.Body.String())
+	}
+}
@@ -0,0 +1,99 @@
+package recovery
+
+import (
+	"context"
+	"fmt"
+	nethttp "net/http"
+	"runtime"
+
+	"github.com/mangohow/gowlb/errors"
+	"github.com/mangohow/gowlb/llog"
+	"github.com/mangohow/gowlb/transport/http"
+	"go.uber.org/zap"
+)
+
+const (
+	ReasonInternalServer = "InternalServerError"
+
+	requestIdKeyName = "X-Request-ID"
+	stackSize        = 64 << 10
+)
+
+// HandlerFunc 发生panic时调用, 可用于将panic上报到其它系统
+type HandlerFunc func(ctx context.Context, req, p any, stack []byte)
+
+type options struct {
+	handler HandlerFunc
+}
+
+type Option func(*options)
+
+// WithHandler 设置发生panic时的回调函数
+func WithHandler(h HandlerFunc) Option {
+	return func(o *options) {
+		o.handler = h
+	}
+}
+
+// Recovery 捕获handler中的panic, 记录堆栈并返回 errors.InternalServer,
+// 由EncodeErrorFunc返回正常的JSON错误, 需要作为第一个中间件注册
+// 只能捕获中间件和业务handler中的panic, 请求解码在中间件之前执行, 结果编码在中间件返回之后执行,
+// 它们中的panic不会被捕获, 由net/http记录日志并断开连接
+func Recovery(opts ...Option) http.Middleware {
+	o := options{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return func(ctx context.Context, req any, handler http.Handler) (resp any, err error) {
+		defer func() {
+			p := recover()
+			if p == nil {
+				return
+			}
+			// 由net/http处理, 用于中断响应
+			if p == nethttp.ErrAbortHandler {
+				panic(p)
+			}
+
+			stack := make([]byte, stackSize)
+			stack = stack[:runtime.Stack(stack, false)]
+
+			logger := llog.FromContext(ctx)
+			if logger == nil {
+				logger = zap.S()
+			}
+			fields := []interface{}{"panic", fmt.Sprint(p), "stack", string(stack)}
+			if rid := requestId(ctx); rid != "" {
+				fields = append(fields, "requestId", rid)
+			}
+			if op, ok := http.OperationFromContext(ctx); ok {
+				fields = append(fields, "operation", op.FullName())
+			}
+			logger.Errorw("Panic recovered", fields...)
+
+			if o.handler != nil {
+				o.handler(ctx, req, p, stack)
+			}
+
+			resp = nil
+			err = errors.InternalServer(errors.UnknownCode, ReasonInternalServer, "internal server error")
+		}()
+
+		return handler(ctx, req)
+	}
+}
+
+// requestId 获取请求ID, 优先使用LoggerInjectMiddleware写入响应头中的请求ID
+func requestId(ctx context.Context) string {
+	c := http.FromContext(ctx)
+	if c == nil {
+		return ""
+	}
+
+	if rid := c.ResponseWriter().Header().Get(requestIdKeyName); rid != "" {
+		return rid
+	}
+
+	return c.Request().Header.Get(requestIdKeyName)
+}
@@ -0,0 +1,111 @@
+package recovery
+
+import (
+	"context"
+	"encoding/json"
+	nethttp "net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mangohow/gowlb/errors"
+	"github.com/mangohow/gowlb/serialize"
+	"github.com/mangohow/gowlb/transport/http"
+)
+
+type panicRequest struct {
+	Name string `json:"name"`
+}
+
+type panicService interface {
+	Panic(ctx context.Context, req *panicRequest) (*panicRequest, error)
+}
+
+type panicServer struct{}
+
+func (panicServer) Panic(ctx context.Context, req *panicRequest) (*panicRequest, error) {
+	if req.Name == "panic" {
+		panic("boom")
+	}
+
+	return req, nil
+}
+
+var panicServiceDesc = &http.ServiceDesc{
+	ServiceName: "test.Panic",
+	HandlerType: (*panicService)(nil),
+	Methods: []http.MethodDesc{
+		{
+			Name:   "Panic",
+			Method: "GET",
+			Path:   "/panic/:name",
+			Handler: func(srv any, ctx context.Context, dec func(any) error, middleware http.Middleware) (any, error) {
+				in := new(panicRequest)
+				if err := dec(in); err != nil {
+					return nil, err
+				}
+				return middleware(ctx, in, func(ctx context.Context, req any) (any, error) {
+					return srv.(panicService).Panic(ctx, req.(*panicRequest))
+				})
+			},
+		},
+	},
+}
+
+func TestRecovery(t *testing.T) {
+	var recovered any
+	s := http.New()
+	s.Middleware(Recovery(WithHandler(func(ctx context.Context, req, p any, stack []byte) {
+		recovered = p
+	})))
+	s.RegisterService(panicServiceDesc, panicServer{})
+
+	w := httptest.NewRecorder()
+	s.HttpServer().Handler.ServeHTTP(w, httptest.NewRequest(nethttp.MethodGet, "/panic/panic", nil))
+	if w.Code != nethttp.StatusInternalServerError || recovered != "boom" {
+		t.Fatalf("status = %d, recovered = %v", w.Code, recovered)
+	}
+
+	resp := &serialize.Response{}
+	if err := json.Unmarshal(w.Body.Bytes(), resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Error == nil || resp.Error.Code() != errors.UnknownCode || resp.Error.Reason() != ReasonInternalServer {
+		t.Errorf("error = %v, want %s", resp.Error, ReasonInternalServer)
+	}
+
+	w = httptest.NewRecorder()
+	s.HttpServer().Handler.ServeHTTP(w, httptest.NewRequest(nethttp.MethodGet, "/panic/tom", nil))
+	if w.Code != nethttp.StatusOK {
+		t.Errorf("status = %d, want 200", w.Code)
+	}
+}
+
+// 请求解码和结果编码在中间件之外执行, 其中的panic不会被Recovery捕获
+func TestRecoveryOutsideMiddleware(t *testing.T) {
+	tests := []struct {
+		name string
+		opt  http.Option
+	}{
+		{"decode", http.WithDecodeRequestFunc(func(ctx *http.Context, desc *http.MethodDesc, v any) error {
+			panic("decode")
+		})},
+		{"encode result", http.WithEncodeResultFunc(func(ctx *http.Context, arg any) {
+			panic("encode result")
+		})},
+	}
+
+	for _, tt := range tests {
+		s := http.New(tt.opt)
+		s.Middleware(Recovery())
+		s.RegisterService(panicServiceDesc, panicServer{})
+
+		func() {
+			defer func() {
+				if p := recover(); p != tt.name {
+					t.Errorf("%s: panic = %v, want %s", tt.name, p, tt.name)
+				}
+			}()
+			s.HttpServer().Handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(nethttp.MethodGet, "/panic/tom", nil))
+		}()
+	}
+}
@@ -0,0 +1,223 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	gerrors "github.com/mangohow/gowlb/errors"
+)
+
+// RetryPolicy 客户端重试策略, 零值字段使用 DefaultRetryPolicy 中的默认值
+type RetryPolicy struct {
+	// 最大尝试次数, 包括第一次请求
+	MaxAttempts int
+	// 第一次重试前的等待时间, 之后每次乘以Multiplier, 最大为MaxBackoff
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+	// 随机抖动的比例, 取值范围为[0, 1], 实际等待时间为 backoff * (1 ± Jitter)
+	Jitter float64
+	// 每次尝试的超时时间, 为0时不限制
+	PerAttemptTimeout time.Duration
+
+	// 需要重试的HTTP状态码
+	RetryableStatus []int
+	// 需要重试的错误原因, 与响应或拦截器返回的 errors.Error 的 Reason 比较
+	RetryableReasons []string
+	// 是否重试连接失败、连接被重置等网络错误
+	RetryNetworkErrors bool
+	// 默认只重试幂等的请求方法, 为true时所有方法都会重试
+	RetryNonIdempotent bool
+}
+
+// DefaultRetryPolicy 默认的重试策略: 最多尝试3次, 重试网络错误以及429、502、503、504
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:        3,
+		InitialBackoff:     100 * time.Millisecond,
+		MaxBackoff:         2 * time.Second,
+		Multiplier:         2,
+		Jitter:             0.2,
+		RetryableStatus:    []int{http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout},
+		RetryNetworkErrors: true,
+	}
+}
+
+// WithRetryPolicy 设置重试策略, 重试时会重新发送缓存的请求体
+func WithRetryPolicy(policy RetryPolicy) ClientOption {
+	return func(c *config) {
+		def := DefaultRetryPolicy()
+		if policy.MaxAttempts <= 0 {
+			policy.MaxAttempts = def.MaxAttempts
+		}
+		if policy.InitialBackoff <= 0 {
+			policy.InitialBackoff = def.InitialBackoff
+		}
+		if policy.MaxBackoff <= 0 {
+			policy.MaxBackoff = def.MaxBackoff
+		}
+		if policy.Multiplier < 1 {
+			policy.Multiplier = def.Multiplier
+		}
+		if policy.Jitter < 0 || policy.Jitter > 1 {
+			policy.Jitter = def.Jitter
+		}
+		c.retryPolicy = &policy
+	}
+}
+
+func (p *RetryPolicy) attempts(method string) int {
+	if p == nil || (!p.RetryNonIdempotent && !isIdempotent(method)) {
+		return 1
+	}
+
+	return p.MaxAttempts
+}
+
+// backoff 返回第attempt次重试前的等待时间
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	backoff := float64(p.InitialBackoff) * math.Pow(p.Multiplier, float64(attempt-1))
+	if backoff > float64(p.MaxBackoff) {
+		backoff = float64(p.MaxBackoff)
+	}
+	backoff *= 1 + p.Jitter*(rand.Float64()*2-1)
+
+	return time.Duration(backoff)
+}
+
+// shouldRetry 根据错误原因、状态码和网络错误判断是否需要重试
+// 拦截器(如熔断器)在发送请求前返回的错误没有响应, 同样根据错误原因判断
+func (p *RetryPolicy) shouldRetry(response *http.Response, err error) bool {
+	if e, ok := gerrors.AsError(err); ok {
+		for _, reason := range p.RetryableReasons {
+			if reason == e.Reason() {
+				return true
+			}
+		}
+	}
+
+	if response == nil {
+		return err != nil && p.RetryNetworkErrors && isNetworkError(err)
+	}
+
+	status := response.StatusCode
+	for _, s := range p.RetryableStatus {
+		if s == status {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+
+	return false
+}
+
+// isNetworkError 判断是否为可以重试的网络错误, 例如连接失败、连接被重置以及超时
+func isNetworkError(err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// do 通过拦截器发送请求, 并根据重试策略进行重试
+// 请求体在创建请求时已经缓存, 每次重试都通过GetBody重新获取
+func (c *Client) do(request *http.Request, operation string, req, reply any) (*CallInfo, error) {
+	ctx := request.Context()
+	policy := c.config.retryPolicy
+	attempts := policy.attempts(request.Method)
+	if request.Body != nil && request.Body != http.NoBody && request.GetBody == nil {
+		attempts = 1
+	}
+
+	for attempt := 1; ; attempt++ {
+		info, err := c.attempt(request, attempt, operation, req, reply)
+		if attempt >= attempts || ctx.Err() != nil || !policy.shouldRetry(info.Response, err) {
+			return info, err
+		}
+
+		timer := time.NewTimer(policy.backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return info, err
+		case <-timer.C:
+		}
+	}
+}
+
+func (c *Client) attempt(request *http.Request, attempt int, operation string, req, reply any) (*CallInfo, error) {
+	info := &CallInfo{
+		Operation: operation,
+		Request:   request,
+		Attempt:   attempt,
+	}
+	if attempt > 1 {
+		info.Request = request.Clone(request.Context())
+		if request.GetBody != nil {
+			body, err := request.GetBody()
+			if err != nil {
+				return info, err
+			}
+			info.Request.Body = body
+		}
+	}
+
+	endpoint, done, err := c.pick(info.Request.URL)
+	if err != nil {
+		return info, err
+	}
+	info.Endpoint = endpoint
+
+	ctx := info.Request.Context()
+	if policy := c.config.retryPolicy; policy != nil && policy.PerAttemptTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, policy.PerAttemptTimeout)
+		defer cancel()
+	}
+
+	invoker := func(ctx context.Context, _ any) (any, error) {
+		response, err := c.client.Do(info.Request.WithContext(ctx))
+		if err != nil {
+			return nil, err
+		}
+		defer response.Body.Close()
+
+		body, err := io.ReadAll(response.Body)
+		if err != nil {
+			return nil, err
+		}
+		info.Response = response
+		if response.StatusCode >= 400 {
+			return nil, errorFromBody(response, body)
+		}
+
+		return reply, decodeReply(response, body, reply)
+	}
+
+	err = c.intercept(ctx, info, req, invoker)
+	done(endpointError(info.Response, err))
+
+	return info, err
+}
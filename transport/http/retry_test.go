@@ -0,0 +1,130 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type echoBody struct {
+	Name string `json:"name"`
+}
+
+// flakyServer 前fails次请求返回failStatus和failBody, 之后返回200并回显请求体
+func flakyServer(fails int32, failStatus int, failBody string, calls *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if atomic.AddInt32(calls, 1) <= fails {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(failStatus)
+			io.WriteString(w, failBody)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if len(body) == 0 {
+			body = []byte(`{}`)
+		}
+		io.WriteString(w, `{"data":`+string(body)+`,"error":null}`)
+	}))
+}
+
+func TestClientRetry(t *testing.T) {
+	policy := RetryPolicy{
+		MaxAttempts:     3,
+		InitialBackoff:  time.Millisecond,
+		RetryableStatus: []int{http.StatusServiceUnavailable},
+	}
+
+	t.Run("idempotent", func(t *testing.T) {
+		var calls int32
+		srv := flakyServer(2, http.StatusServiceUnavailable, "", &calls)
+		defer srv.Close()
+
+		client, err := NewClient(WithEndpoint(srv.URL), WithRetryPolicy(policy))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		reply := &echoBody{}
+		status, err := client.Invoke(context.Background(), http.MethodPut, "/", &echoBody{Name: "gowlb"}, reply)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status != http.StatusOK || calls != 3 || reply.Name != "gowlb" {
+			t.Fatalf("status=%d calls=%d reply=%+v", status, calls, reply)
+		}
+	})
+
+	t.Run("non-idempotent", func(t *testing.T) {
+		var calls int32
+		srv := flakyServer(2, http.StatusServiceUnavailable, "", &calls)
+		defer srv.Close()
+
+		client, err := NewClient(WithEndpoint(srv.URL), WithRetryPolicy(policy))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		status, _ := client.Invoke(context.Background(), http.MethodPost, "/", &echoBody{Name: "gowlb"}, &echoBody{})
+		if status != http.StatusServiceUnavailable || calls != 1 {
+			t.Fatalf("status=%d calls=%d", status, calls)
+		}
+	})
+
+	t.Run("reason", func(t *testing.T) {
+		var calls int32
+		body := `{"data":null,"error":{"code":1,"reason":"Busy","message":"try later"}}`
+		srv := flakyServer(1, http.StatusConflict, body, &calls)
+		defer srv.Close()
+
+		p := policy
+		p.RetryableReasons = []string{"Busy"}
+		client, err := NewClient(WithEndpoint(srv.URL), WithRetryPolicy(p))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		status, err := client.Invoke(context.Background(), http.MethodGet, "/", nil, &echoBody{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status != http.StatusOK || calls != 2 {
+			t.Fatalf("status=%d calls=%d", status, calls)
+		}
+	})
+
+	t.Run("per-attempt timeout", func(t *testing.T) {
+		var calls int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&calls, 1) == 1 {
+				select {
+				case <-r.Context().Done():
+				case <-time.After(time.Second):
+				}
+				return
+			}
+			io.WriteString(w, `{"data":{},"error":null}`)
+		}))
+		defer srv.Close()
+
+		p := policy
+		p.PerAttemptTimeout = 50 * time.Millisecond
+		p.RetryNetworkErrors = true
+		client, err := NewClient(WithEndpoint(srv.URL), WithRetryPolicy(p))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		status, err := client.Invoke(context.Background(), http.MethodGet, "/", nil, &echoBody{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status != http.StatusOK || calls != 2 {
+			t.Fatalf("status=%d calls=%d", status, calls)
+		}
+	})
+}
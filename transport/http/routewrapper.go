@@ -1,9 +1,18 @@
 package http
 
 import (
+	"context"
 	"net/http"
+	"sort"
+	"strings"
 
 	"github.com/gorilla/mux"
+	"github.com/mangohow/gowlb/errors"
+)
+
+const (
+	ReasonNotFound         = "NotFound"
+	ReasonMethodNotAllowed = "MethodNotAllowed"
 )
 
 type routeWrapper struct {
@@ -13,21 +22,39 @@ type routeWrapper struct {
 }
 
 func newRouterWrapper(errorEncoder EncodeErrorFunc, s *Server) *routeWrapper {
-	return &routeWrapper{
+	r := &routeWrapper{
 		mu:           mux.NewRouter(),
 		s:            s,
 		errorEncoder: errorEncoder,
 	}
+	r.mu.NotFoundHandler = http.HandlerFunc(r.notFound)
+	r.mu.MethodNotAllowedHandler = http.HandlerFunc(r.methodNotAllowed)
+
+	return r
 }
 
 type HandlerFunc func(ctx *Context) error
 
 func (r *routeWrapper) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if r.s.cors != nil && r.s.cors.handle(w, req, r.allowedMethods) {
+		return
+	}
+
+	if c := r.s.compression; c != nil {
+		if cw := c.newWriter(w, req); cw != nil {
+			defer cw.close()
+			w = cw
+		}
+		if err := c.decompress(req); err != nil {
+			r.handleError(w, req, err)
+			return
+		}
+	}
 	r.mu.ServeHTTP(w, req)
 }
 
 func (r *routeWrapper) HandleFunc(method string, path string, handler HandlerFunc) {
-	r.mu.HandleFunc(path, func(w http.ResponseWriter, req *http.Request) {
+	r.mu.HandleFunc(toMuxPath(path), func(w http.ResponseWriter, req *http.Request) {
 		ctx := newContext(w, req, r.s)
 		defer putContext(ctx)
 		if err := handler(ctx); err != nil {
@@ -37,6 +64,62 @@ func (r *routeWrapper) HandleFunc(method string, path string, handler HandlerFun
 	}).Methods(method)
 }
 
+func (r *routeWrapper) notFound(w http.ResponseWriter, req *http.Request) {
+	r.handleError(w, req, errors.NotFound(errors.UnknownCode, ReasonNotFound, "no route matches "+req.Method+" "+req.URL.Path))
+}
+
+// methodNotAllowed 路径存在但是请求方法不匹配, 通过Allow头返回该路径允许的请求方法
+func (r *routeWrapper) methodNotAllowed(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Allow", strings.Join(r.allowedMethods(req), ", "))
+	r.handleError(w, req, errors.MethodNotAllowed(errors.UnknownCode, ReasonMethodNotAllowed, "method "+req.Method+" is not allowed for "+req.URL.Path))
+}
+
+// handleError 没有匹配到路由的请求同样经过Server的中间件, 之后由errorEncoder返回错误
+// 此时 OperationFromContext 返回空的Operation
+func (r *routeWrapper) handleError(w http.ResponseWriter, req *http.Request, err error) {
+	ctx := newContext(w, req, r.s)
+	defer putContext(ctx)
+
+	_, err = chainHandler(r.s.middlewares)(context.WithValue(r.s.ctx, ctxKey, ctx), nil, func(context.Context, any) (any, error) {
+		return nil, err
+	})
+	if err != nil {
+		r.errorEncoder(ctx, err)
+	}
+}
+
+// allowedMethods 返回路径与req匹配的所有路由注册的请求方法
+func (r *routeWrapper) allowedMethods(req *http.Request) []string {
+	var allowed []string
+	_ = r.mu.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+		for _, method := range methods {
+			probe := *req
+			probe.Method = method
+			if route.Match(&probe, &mux.RouteMatch{}) && !contains(allowed, method) {
+				allowed = append(allowed, method)
+			}
+		}
+		return nil
+	})
+	sort.Strings(allowed)
+
+	return allowed
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (r *routeWrapper) GET(path string, handler HandlerFunc) {
 	r.HandleFunc(http.MethodGet, path, handler)
 }
@@ -56,3 +139,19 @@ func (r *routeWrapper) PATCH(path string, handler HandlerFunc) {
 func (r *routeWrapper) DELETE(path string, handler HandlerFunc) {
 	r.HandleFunc(http.MethodDelete, path, handler)
 }
+
+// toMuxPath 将 /api/user/:id 形式的路径转换为gorilla/mux的 /api/user/{id} 形式
+func toMuxPath(path string) string {
+	if !strings.Contains(path, "/:") {
+		return path
+	}
+
+	segments := strings.Split(path, "/")
+	for i, seg := range segments {
+		if strings.HasPrefix(seg, ":") && len(seg) > 1 {
+			segments[i] = "{" + seg[1:] + "}"
+		}
+	}
+
+	return strings.Join(segments, "/")
+}
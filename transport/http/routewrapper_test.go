@@ -0,0 +1,53 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestUnmatchedRoute(t *testing.T) {
+	s := New()
+	s.RegisterService(userServiceDesc, userServer{})
+	s.router.DELETE("/user/:name", func(ctx *Context) error {
+		return nil
+	})
+	var errs []error
+	s.Middleware(func(ctx context.Context, req any, handler Handler) (any, error) {
+		resp, err := handler(ctx, req)
+		errs = append(errs, err)
+		return resp, err
+	})
+
+	tests := []struct {
+		method, path string
+		status       int
+		reason       string
+		allow        string
+	}{
+		{http.MethodGet, "/missing", http.StatusNotFound, ReasonNotFound, ""},
+		{http.MethodPost, "/user/tom", http.StatusMethodNotAllowed, ReasonMethodNotAllowed, "DELETE, GET"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		s.HttpServer().Handler.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+
+		var body struct {
+			Error struct {
+				Reason string `json:"reason"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("%s %s body = %q, %v", tt.method, tt.path, w.Body.String(), err)
+		}
+		if w.Code != tt.status || body.Error.Reason != tt.reason || w.Header().Get("Allow") != tt.allow {
+			t.Errorf("%s %s = %d %s, Allow = %q", tt.method, tt.path, w.Code, body.Error.Reason, w.Header().Get("Allow"))
+		}
+	}
+
+	if len(errs) != 2 || errs[0] == nil || errs[1] == nil {
+		t.Errorf("middleware errors = %v", errs)
+	}
+}
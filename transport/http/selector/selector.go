@@ -0,0 +1,108 @@
+package selector
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/mangohow/gowlb/transport/http"
+)
+
+// MatchFunc 自定义匹配函数
+type MatchFunc func(ctx context.Context, op http.Operation) bool
+
+// Builder 根据当前请求的Operation决定中间件是否生效
+// Prefix、Regex、Path 同时匹配 Operation.FullName() 和路由模板 Operation.Pattern,
+// 任意一个条件匹配即视为匹配
+//
+//	selector.Server(auth).Path("/health").Exclude()
+//	selector.Server(audit).Regex(`/.*/(Create|Update|Delete).*`).Build()
+type Builder struct {
+	prefix []string
+	regex  []*regexp.Regexp
+	path   map[string]struct{}
+	match  MatchFunc
+
+	middleware http.Middleware
+}
+
+// Server 创建选择器, middlewares按照参数顺序执行
+func Server(middlewares ...http.Middleware) *Builder {
+	return &Builder{
+		path:       make(map[string]struct{}),
+		middleware: http.Chain(middlewares...),
+	}
+}
+
+// Prefix 前缀匹配
+func (b *Builder) Prefix(prefix ...string) *Builder {
+	b.prefix = append(b.prefix, prefix...)
+	return b
+}
+
+// Regex 正则匹配, 正则表达式不合法时panic
+func (b *Builder) Regex(regex ...string) *Builder {
+	for _, r := range regex {
+		b.regex = append(b.regex, regexp.MustCompile(r))
+	}
+	return b
+}
+
+// Path 精确匹配
+func (b *Builder) Path(path ...string) *Builder {
+	for _, p := range path {
+		b.path[p] = struct{}{}
+	}
+	return b
+}
+
+// Match 自定义匹配
+func (b *Builder) Match(fn MatchFunc) *Builder {
+	b.match = fn
+	return b
+}
+
+// Build 中间件只对匹配的Operation生效
+func (b *Builder) Build() http.Middleware {
+	return b.selector(true)
+}
+
+// Exclude 中间件对除匹配的Operation以外的请求生效
+func (b *Builder) Exclude() http.Middleware {
+	return b.selector(false)
+}
+
+func (b *Builder) selector(want bool) http.Middleware {
+	return func(ctx context.Context, req any, handler http.Handler) (any, error) {
+		op, _ := http.OperationFromContext(ctx)
+		if b.matches(ctx, op) != want {
+			return handler(ctx, req)
+		}
+
+		return b.middleware(ctx, req, handler)
+	}
+}
+
+func (b *Builder) matches(ctx context.Context, op http.Operation) bool {
+	for _, target := range [...]string{op.FullName(), op.Pattern} {
+		if _, ok := b.path[target]; ok {
+			return true
+		}
+		for _, prefix := range b.prefix {
+			if strings.HasPrefix(target, prefix) {
+				return true
+			}
+		}
+		for _, regex := range b.regex {
+			if regex.MatchString(target) {
+				return true
+			}
+		}
+	}
+
+	if b.match != nil {
+		return b.match(ctx, op)
+	}
+
+	return false
+}
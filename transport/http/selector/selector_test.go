@@ -0,0 +1,82 @@
+package selector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mangohow/gowlb/transport/http"
+)
+
+func TestSelector(t *testing.T) {
+	greeter := http.Operation{Service: "helloworld.v1.Greeter", Method: "SayHello", HTTPMethod: "GET", Pattern: "/api/hello/:name"}
+	user := http.Operation{Service: "user.v1.User", Method: "CreateUser", HTTPMethod: "POST", Pattern: "/api/users"}
+	health := http.Operation{Service: "health.v1.Health", Method: "Check", HTTPMethod: "GET", Pattern: "/health"}
+
+	tests := []struct {
+		name    string
+		builder func(m http.Middleware) *Builder
+		exclude bool
+		op      http.Operation
+		want    bool
+	}{
+		{"prefix full name", func(m http.Middleware) *Builder { return Server(m).Prefix("/helloworld.v1.") }, false, greeter, true},
+		{"prefix pattern", func(m http.Middleware) *Builder { return Server(m).Prefix("/api/") }, false, user, true},
+		{"prefix no match", func(m http.Middleware) *Builder { return Server(m).Prefix("/api/") }, false, health, false},
+		{"path full name", func(m http.Middleware) *Builder { return Server(m).Path("/user.v1.User/CreateUser") }, false, user, true},
+		{"path pattern", func(m http.Middleware) *Builder { return Server(m).Path("/health") }, false, health, true},
+		{"path is not prefix", func(m http.Middleware) *Builder { return Server(m).Path("/api") }, false, user, false},
+		{"regex", func(m http.Middleware) *Builder { return Server(m).Regex(`/.*/(Create|Update|Delete).*`) }, false, user, true},
+		{"regex no match", func(m http.Middleware) *Builder { return Server(m).Regex(`/.*/(Create|Update|Delete).*`) }, false, greeter, false},
+		{"match func", func(m http.Middleware) *Builder {
+			return Server(m).Match(func(ctx context.Context, op http.Operation) bool { return op.HTTPMethod == "POST" })
+		}, false, user, true},
+		{"any condition", func(m http.Middleware) *Builder { return Server(m).Prefix("/admin").Path("/health") }, false, health, true},
+		{"exclude match", func(m http.Middleware) *Builder { return Server(m).Path("/health") }, true, health, false},
+		{"exclude no match", func(m http.Middleware) *Builder { return Server(m).Path("/health") }, true, greeter, true},
+	}
+
+	for _, tt := range tests {
+		called := false
+		m := func(ctx context.Context, req any, handler http.Handler) (any, error) {
+			called = true
+			return handler(ctx, req)
+		}
+		b := tt.builder(m)
+		mw := b.Build()
+		if tt.exclude {
+			mw = b.Exclude()
+		}
+
+		handled := false
+		reply, err := mw(http.NewOperationContext(context.Background(), tt.op), "req", func(ctx context.Context, req any) (any, error) {
+			handled = true
+			return req, nil
+		})
+		if err != nil || reply != "req" || !handled {
+			t.Errorf("%s: handler not called, reply = %v, err = %v", tt.name, reply, err)
+		}
+		if called != tt.want {
+			t.Errorf("%s: middleware called = %v, want %v", tt.name, called, tt.want)
+		}
+	}
+}
+
+func TestSelectorChain(t *testing.T) {
+	var trace []string
+	record := func(name string) http.Middleware {
+		return func(ctx context.Context, req any, handler http.Handler) (any, error) {
+			trace = append(trace, name)
+			return handler(ctx, req)
+		}
+	}
+
+	mw := Server(record("a"), record("b")).Prefix("/api").Build()
+	ctx := http.NewOperationContext(context.Background(), http.Operation{Service: "s", Method: "m", Pattern: "/api/users"})
+	mw(ctx, nil, func(ctx context.Context, req any) (any, error) {
+		trace = append(trace, "handler")
+		return nil, nil
+	})
+	if len(trace) != 3 || trace[0] != "a" || trace[1] != "b" || trace[2] != "handler" {
+		t.Errorf("trace = %v, want [a b handler]", trace)
+	}
+}
@@ -2,13 +2,19 @@ package http
 
 import (
 	"context"
+	"crypto/tls"
+	"net"
 	"net/http"
 	"reflect"
+	"time"
 
+	"github.com/gorilla/websocket"
 	"github.com/mangohow/gowlb/errors"
 	"github.com/mangohow/gowlb/serialize"
 	"github.com/mangohow/gowlb/transport/binding"
 	"github.com/sirupsen/logrus"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 )
 
 const (
@@ -30,10 +36,27 @@ type Server struct {
 	pathVarBinding binding.Binding
 	bodyBinding    binding.Binding
 
-	resultEncoder EncodeResultFunc
+	maxMultipartMemory int64
+
+	resultEncoder  EncodeResultFunc
+	requestDecoder DecodeRequestFunc
 
 	middlewares []Middleware
 
+	tlsConfig    *tls.Config
+	certFile     string
+	keyFile      string
+	clientCAFile string
+
+	listener net.Listener
+	h2c      bool
+
+	wsUpgrader     *websocket.Upgrader
+	wsPingInterval time.Duration
+
+	cors        *CORS
+	compression *Compression
+
 	ctx context.Context
 }
 
@@ -42,12 +65,8 @@ type EncodeErrorFunc func(ctx *Context, err error)
 
 // DefaultEncodeErrorFunc 默认错误处理函数
 func DefaultEncodeErrorFunc(ctx *Context, err error) {
-	e, ok := err.(errors.Error)
-	if !ok {
-		e = errors.FromError(errors.UnknownCode, errors.DefaultStatus, errors.UnknownReason, errors.UnknownMessage, err)
-	}
-
-	err = ctx.JSON(int(e.HttpStatus()), serialize.Response{
+	e := toError(err)
+	err = ctx.Render(int(e.HttpStatus()), serialize.Response{
 		Error: e,
 	})
 	if err != nil {
@@ -57,10 +76,32 @@ func DefaultEncodeErrorFunc(ctx *Context, err error) {
 	return
 }
 
+// toError 将err转换为errors.Error, 未知的错误作为服务端内部错误处理
+func toError(err error) errors.Error {
+	e, ok := err.(errors.Error)
+	if !ok {
+		e = errors.FromError(errors.UnknownCode, errors.DefaultStatus, errors.UnknownReason, errors.UnknownMessage, err)
+	}
+
+	return e
+}
+
+// EncodeResultFunc 结果处理函数
 type EncodeResultFunc func(ctx *Context, arg any)
 
+// DefaultEncodeResultFunc 默认结果处理函数, 将结果包装在serialize.Response中返回
+func DefaultEncodeResultFunc(ctx *Context, arg any) {
+	err := ctx.Render(http.StatusOK, serialize.Response{
+		Data: arg,
+	})
+	if err != nil {
+		ctx.s.errorEncoder(ctx, err)
+	}
+}
+
 type Option func(s *Server)
 
+// WithAddr 设置监听地址, 以 unix:// 开头时监听 Unix domain socket, 例如 unix:///var/run/app.sock
 func WithAddr(addr string) Option {
 	return func(s *Server) {
 		if addr == "" {
@@ -76,6 +117,19 @@ func WithEncodeErrorFunc(fn EncodeErrorFunc) Option {
 	}
 }
 
+func WithEncodeResultFunc(fn EncodeResultFunc) Option {
+	return func(s *Server) {
+		s.resultEncoder = fn
+	}
+}
+
+// WithDecodeRequestFunc 设置请求解码函数, 默认为DefaultDecodeRequestFunc
+func WithDecodeRequestFunc(fn DecodeRequestFunc) Option {
+	return func(s *Server) {
+		s.requestDecoder = fn
+	}
+}
+
 func WithQueryBinding(bind binding.Binding) Option {
 	return func(s *Server) {
 		s.queryBinding = bind
@@ -88,6 +142,13 @@ func WithFormBinding(bind binding.Binding) Option {
 	}
 }
 
+// WithMaxMultipartMemory 设置默认form binding解析multipart表单时使用的最大内存
+func WithMaxMultipartMemory(size int64) Option {
+	return func(s *Server) {
+		s.maxMultipartMemory = size
+	}
+}
+
 func WithPathVarBinding(bind binding.Binding) Option {
 	return func(s *Server) {
 		s.pathVarBinding = bind
@@ -112,26 +173,74 @@ func WithContext(ctx context.Context) Option {
 	}
 }
 
+// WithListener 使用指定的listener, 设置后忽略WithAddr, 可以用于在测试中注入内存listener
+func WithListener(listener net.Listener) Option {
+	return func(s *Server) {
+		s.listener = listener
+	}
+}
+
+// WithH2C 在非TLS连接上支持HTTP/2(h2c), 同时兼容HTTP/1.1
+func WithH2C() Option {
+	return func(s *Server) {
+		s.h2c = true
+	}
+}
+
+// WithWebSocketUpgrader 设置双向流式方法使用的Upgrader, 可以用于设置跨域检查和缓冲区大小
+func WithWebSocketUpgrader(upgrader *websocket.Upgrader) Option {
+	return func(s *Server) {
+		s.wsUpgrader = upgrader
+	}
+}
+
+// WithWebSocketPingInterval 设置WebSocket连接发送ping的间隔, 默认为30s, 小于等于0时不发送
+// 超过两倍间隔没有收到pong时认为连接已经断开
+func WithWebSocketPingInterval(interval time.Duration) Option {
+	return func(s *Server) {
+		s.wsPingInterval = interval
+	}
+}
+
+// WithTLSConfig 使用TLS启动服务, 可以与WithCertFiles、WithClientCAFile同时使用
+func WithTLSConfig(cfg *tls.Config) Option {
+	return func(s *Server) {
+		s.tlsConfig = cfg
+	}
+}
+
+// WithCertFiles 使用证书文件启动TLS服务, 证书文件修改后会自动重新加载
+func WithCertFiles(certFile, keyFile string) Option {
+	return func(s *Server) {
+		s.certFile = certFile
+		s.keyFile = keyFile
+	}
+}
+
+// WithClientCAFile 开启mTLS, 客户端必须提供由caFile签发的证书
+// 验证通过的客户端身份可以通过 Context.Peer 获取
+func WithClientCAFile(caFile string) Option {
+	return func(s *Server) {
+		s.clientCAFile = caFile
+	}
+}
+
 func New(opts ...Option) *Server {
 	s := &Server{}
 	for _, opt := range opts {
 		opt(s)
 	}
 
-	s.server = &http.Server{
-		Handler: s.router,
-	}
-
 	if s.queryBinding == nil {
 		s.queryBinding = binding.QueryBinding{Tag: "json"}
 	}
 
 	if s.formBinding == nil {
-		s.formBinding = binding.FormBinding{}
+		s.formBinding = binding.FormBinding{Tag: FormKey, MaxMemory: s.maxMultipartMemory}
 	}
 
 	if s.pathVarBinding == nil {
-		s.pathVarBinding = binding.PathVarBinding{}
+		s.pathVarBinding = binding.PathVarBinding{Tag: ParamKey}
 	}
 
 	if s.bodyBinding == nil {
@@ -142,6 +251,14 @@ func New(opts ...Option) *Server {
 		s.errorEncoder = DefaultEncodeErrorFunc
 	}
 
+	if s.resultEncoder == nil {
+		s.resultEncoder = DefaultEncodeResultFunc
+	}
+
+	if s.requestDecoder == nil {
+		s.requestDecoder = DefaultDecodeRequestFunc
+	}
+
 	if s.router == nil {
 		s.router = newRouterWrapper(s.errorEncoder, s)
 	}
@@ -157,7 +274,19 @@ func New(opts ...Option) *Server {
 	if s.addr == "" {
 		s.addr = ":8000"
 	}
-	s.server.Addr = s.addr
+
+	if s.wsPingInterval == 0 {
+		s.wsPingInterval = defaultPingInterval
+	}
+
+	var handler http.Handler = s.router
+	if s.h2c {
+		handler = h2c.NewHandler(handler, &http2.Server{})
+	}
+	s.server = &http.Server{
+		Addr:    s.addr,
+		Handler: handler,
+	}
 
 	if s.log == nil {
 		s.log = logrus.StandardLogger()
@@ -170,7 +299,19 @@ func (s *Server) HttpServer() *http.Server {
 	return s.server
 }
 
+// ServiceRegistrar 用于注册服务, Server和Group都实现了该接口
+type ServiceRegistrar interface {
+	RegisterService(sd *ServiceDesc, srv interface{})
+}
+
 func (s *Server) RegisterService(sd *ServiceDesc, srv interface{}) {
+	s.checkHandlerType(sd, srv)
+	s.register(sd, srv, "", func() []Middleware {
+		return s.middlewares
+	})
+}
+
+func (s *Server) checkHandlerType(sd *ServiceDesc, srv interface{}) {
 	if srv != nil {
 		ht := reflect.TypeOf(sd.HandlerType).Elem()
 		st := reflect.TypeOf(srv)
@@ -178,15 +319,74 @@ func (s *Server) RegisterService(sd *ServiceDesc, srv interface{}) {
 			s.log.Fatalf("handler type %v not implement %v", st, ht)
 		}
 	}
-
-	s.register(sd, srv)
 }
 
-func (s *Server) register(sd *ServiceDesc, srv interface{}) {
-	for _, d := range sd.Methods {
-		handler := d.Handler
-		s.handle(d.Method, d.Path, func(ctx context.Context, req interface{}) (resp interface{}, err error) {
-			return handler(ctx, srv, chainHandler(s.middlewares))
+// register 注册服务中的所有方法, prefix为路径前缀, middlewares在每次请求时获取中间件,
+// 使得注册服务之后添加的中间件同样生效
+func (s *Server) register(sd *ServiceDesc, srv interface{}, prefix string, middlewares func() []Middleware) {
+	for i := range sd.Methods {
+		desc := &sd.Methods[i]
+		op := Operation{
+			Service:    sd.ServiceName,
+			Method:     desc.Name,
+			HTTPMethod: desc.Method,
+			Pattern:    joinPath(prefix, desc.Path),
+		}
+		s.router.HandleFunc(op.HTTPMethod, op.Pattern, func(c *Context) error {
+			c.op = op
+			ctx := context.WithValue(s.ctx, ctxKey, c)
+			dec := func(v any) error {
+				return s.requestDecoder(c, desc, v)
+			}
+
+			resp, err := desc.Handler(srv, ctx, dec, chainHandler(middlewares()))
+			if err != nil {
+				return err
+			}
+
+			if s.resultEncoder != nil {
+				s.resultEncoder(c, resp)
+			}
+
+			return nil
+		})
+	}
+
+	for i := range sd.Streams {
+		desc := &sd.Streams[i]
+		op := Operation{
+			Service:    sd.ServiceName,
+			Method:     desc.Name,
+			HTTPMethod: desc.Method,
+			Pattern:    joinPath(prefix, desc.Path),
+		}
+		// 流式方法与普通方法使用相同的请求解码规则
+		methodDesc := &MethodDesc{Name: desc.Name, Method: desc.Method, Path: desc.Path, Body: desc.Body}
+		s.router.HandleFunc(op.HTTPMethod, op.Pattern, func(c *Context) error {
+			c.op = op
+			// 客户端断开连接时取消ctx, 从而结束流
+			ctx, cancel := context.WithCancel(context.WithValue(s.ctx, ctxKey, c))
+			defer cancel()
+			reqCtx := c.req.Context()
+			go func() {
+				select {
+				case <-reqCtx.Done():
+					cancel()
+				case <-ctx.Done():
+				}
+			}()
+
+			dec := func(v any) error {
+				return s.requestDecoder(c, methodDesc, v)
+			}
+			var stream finishableStream
+			if desc.ClientStreams {
+				stream = newWebSocketStream(ctx, cancel, c)
+			} else {
+				stream = newServerStream(ctx, c)
+			}
+
+			return stream.finish(desc.Handler(srv, ctx, dec, stream, chainHandler(middlewares())))
 		})
 	}
 }
@@ -213,33 +413,38 @@ func getChainMiddleware(middlewares []Middleware, cur int, handler Handler) Hand
 	}
 }
 
-func (s *Server) handle(method, relativePath string, handler Handler) {
-	s.router.HandleFunc(method, relativePath, s.handlerConvert(handler))
+func (s *Server) Middleware(middleware ...Middleware) {
+	s.middlewares = append(s.middlewares, middleware...)
 }
 
-func (s *Server) handlerConvert(handler Handler) HandlerFunc {
-	return func(c *Context) error {
-		ctx := context.WithValue(s.ctx, ctxKey, c)
-		resp, err := handler(ctx, nil)
-		if err != nil {
-			return err
-		}
-
-		if s.resultEncoder != nil {
-			s.resultEncoder(c, resp)
-		}
-
-		return nil
+// Group 创建路由分组, 分组中注册的服务路径都会添加prefix前缀,
+// 并且在全局中间件之后执行分组的中间件
+func (s *Server) Group(prefix string, middlewares ...Middleware) *Group {
+	return &Group{
+		s:           s,
+		prefix:      joinPath(prefix, ""),
+		middlewares: middlewares,
 	}
 }
 
-func (s *Server) Middleware(middleware ...Middleware) {
-	s.middlewares = append(s.middlewares, middleware...)
-}
-
 func (s *Server) Start() error {
-	s.log.Info("server listen at ", s.addr)
-	err := s.server.ListenAndServe()
+	tlsConfig, err := s.buildTLSConfig()
+	if err != nil {
+		return err
+	}
+
+	ln, err := s.listen()
+	if err != nil {
+		return err
+	}
+
+	s.log.Info("server listen at ", ln.Addr())
+	if tlsConfig != nil {
+		s.server.TLSConfig = tlsConfig
+		err = s.server.ServeTLS(ln, "", "")
+	} else {
+		err = s.server.Serve(ln)
+	}
 	if err == http.ErrServerClosed {
 		return nil
 	}
@@ -0,0 +1,280 @@
+package http
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/mangohow/gowlb/encoding"
+	"github.com/mangohow/gowlb/encoding/json"
+	"github.com/mangohow/gowlb/serialize"
+)
+
+const (
+	ContentTypeEventStream = "text/event-stream"
+	ContentTypeNDJSON      = "application/x-ndjson"
+)
+
+// streamHandler 由protoc-gen-go-http为服务端流式rpc方法生成
+type streamHandler func(srv any, ctx context.Context, dec func(any) error, stream ServerStream, middleware Middleware) error
+
+// StreamDesc 流式rpc方法
+// 服务端流式方法的响应以SSE或NDJSON的形式返回, 双向流式方法通过WebSocket传输
+type StreamDesc struct {
+	// rpc 方法名
+	Name   string
+	Method string
+	Path   string
+	// google.api.http 中的body
+	Body    string
+	Handler streamHandler
+	// 为true时表示双向流式方法
+	ClientStreams bool
+}
+
+// ServerStream 服务端流, 生成代码中的 <Service>_<Method>HTTPServer 通过它收发消息
+type ServerStream interface {
+	Context() context.Context
+	SendMsg(m any) error
+	// RecvMsg 接收客户端发送的消息, 客户端结束发送时返回io.EOF
+	RecvMsg(m any) error
+}
+
+// finishableStream 在handler返回后结束流
+type finishableStream interface {
+	ServerStream
+	finish(err error) error
+}
+
+// serverStream 每条消息都包装在serialize.Response中:
+// text/event-stream: 每条消息为一个事件, 错误的事件类型为error
+// application/x-ndjson: 每条消息为一行json
+type serverStream struct {
+	ctx         context.Context
+	c           *Context
+	contentType string
+	started     bool
+}
+
+func newServerStream(ctx context.Context, c *Context) *serverStream {
+	return &serverStream{
+		ctx:         ctx,
+		c:           c,
+		contentType: c.Negotiate(ContentTypeEventStream, ContentTypeNDJSON),
+	}
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *serverStream) SendMsg(m any) error {
+	if err := s.ctx.Err(); err != nil {
+		return err
+	}
+
+	return s.write("", serialize.Response{Data: m})
+}
+
+// RecvMsg 服务端流式方法只有一个请求参数, 已经通过dec解码
+func (s *serverStream) RecvMsg(m any) error {
+	return io.EOF
+}
+
+// start 写入响应头, 在发送第一条消息之前出现的错误仍然由EncodeErrorFunc处理
+func (s *serverStream) start() {
+	if s.started {
+		return
+	}
+	s.started = true
+
+	header := s.c.w.Header()
+	header.Set("Content-Type", s.contentType)
+	header.Set("Cache-Control", "no-cache")
+	// 禁用nginx的响应缓冲
+	header.Set("X-Accel-Buffering", "no")
+	s.c.w.WriteHeader(http.StatusOK)
+}
+
+func (s *serverStream) write(event string, resp serialize.Response) error {
+	data, err := resp.MarshalJSON()
+	if err != nil {
+		return err
+	}
+
+	s.start()
+	buf := &bytes.Buffer{}
+	if s.contentType == ContentTypeEventStream {
+		if event != "" {
+			buf.WriteString("event: " + event + "\n")
+		}
+		buf.WriteString("data: ")
+		buf.Write(data)
+		buf.WriteString("\n\n")
+	} else {
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+
+	if _, err = s.c.w.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	if flusher, ok := s.c.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+
+	return nil
+}
+
+// finish 结束流, 已经开始发送消息后出现的错误作为最后一条消息发送
+func (s *serverStream) finish(err error) error {
+	if err == nil {
+		s.start()
+		return nil
+	}
+	if !s.started {
+		return err
+	}
+
+	_ = s.write("error", serialize.Response{Error: toError(err)})
+	return nil
+}
+
+// ClientStream 客户端流, 生成代码中的 <Service>_<Method>HTTPClient 通过它收发消息
+type ClientStream interface {
+	// SendMsg 发送一条消息, 只有双向流式方法支持
+	SendMsg(m any) error
+	// RecvMsg 接收一条消息, 流结束时返回io.EOF
+	RecvMsg(m any) error
+	// CloseSend 结束发送, 之后仍然可以接收消息
+	CloseSend() error
+	// Close 关闭流并释放连接
+	Close() error
+}
+
+// NewStream 调用服务端流式rpc方法, 建立连接的请求经过客户端拦截器
+func (c *Client) NewStream(ctx context.Context, method, path string, req any, opts ...CallOption) (ClientStream, error) {
+	request, bco, err := c.newRequest(ctx, method, path, req, ContentTypeNDJSON+", "+ContentTypeEventStream+";q=0.9", opts)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint, done, err := c.pick(request.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &CallInfo{
+		Operation: bco.Operation,
+		Endpoint:  endpoint,
+		Request:   request,
+		Attempt:   1,
+	}
+	err = c.intercept(ctx, info, req, func(context.Context, any) (any, error) {
+		response, err := c.client.Do(info.Request)
+		if err != nil {
+			return nil, err
+		}
+		info.Response = response
+		if response.StatusCode < 200 || response.StatusCode >= 300 {
+			defer response.Body.Close()
+			return nil, responseError(response)
+		}
+
+		return nil, nil
+	})
+	done(endpointError(info.Response, err))
+	if err != nil {
+		if info.Response != nil {
+			info.Response.Body.Close()
+		}
+		return nil, err
+	}
+	response := info.Response
+
+	for _, opt := range opts {
+		opt.After(&AfterCallInfo{Status: response.StatusCode})
+	}
+
+	contentType := response.Header.Get("Content-Type")
+	return &clientStream{
+		body:        response.Body,
+		reader:      bufio.NewReader(response.Body),
+		eventStream: strings.HasPrefix(contentType, ContentTypeEventStream),
+		codec:       encoding.GetCodec(json.Name),
+	}, nil
+}
+
+type clientStream struct {
+	body        io.ReadCloser
+	reader      *bufio.Reader
+	eventStream bool
+	codec       encoding.Codec
+}
+
+func (s *clientStream) SendMsg(m any) error {
+	return errors.New("server-streaming rpc does not accept client messages")
+}
+
+func (s *clientStream) CloseSend() error {
+	return nil
+}
+
+func (s *clientStream) RecvMsg(m any) error {
+	data, err := s.next()
+	if err != nil {
+		return err
+	}
+
+	resp := &serialize.Response{Data: m}
+	if err := s.codec.Unmarshal(data, resp); err != nil {
+		return err
+	}
+	if resp.Error != nil {
+		return resp.Error
+	}
+
+	return nil
+}
+
+// next 读取下一条消息的数据, 跳过空行和SSE中的注释
+func (s *clientStream) next() ([]byte, error) {
+	var data []byte
+	for {
+		line, err := s.reader.ReadBytes('\n')
+		if err != nil && (err != io.EOF || len(line) == 0) {
+			if err == io.EOF && len(data) > 0 {
+				return data, nil
+			}
+			return nil, err
+		}
+		line = bytes.TrimRight(line, "\r\n")
+
+		if !s.eventStream {
+			if len(line) > 0 {
+				return line, nil
+			}
+			continue
+		}
+
+		switch {
+		case len(line) == 0:
+			if len(data) > 0 {
+				return data, nil
+			}
+		case bytes.HasPrefix(line, []byte("data:")):
+			if len(data) > 0 {
+				data = append(data, '\n')
+			}
+			data = append(data, bytes.TrimPrefix(bytes.TrimPrefix(line, []byte("data:")), []byte(" "))...)
+		}
+	}
+}
+
+func (s *clientStream) Close() error {
+	return s.body.Close()
+}
@@ -0,0 +1,129 @@
+package http
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/mangohow/gowlb/encoding"
+	"github.com/mangohow/gowlb/encoding/json"
+	"github.com/mangohow/gowlb/errors"
+)
+
+type countRequest struct {
+	N int `json:"n"`
+}
+
+type countReply struct {
+	I int `json:"i"`
+}
+
+type countService interface {
+	Count(ctx context.Context, req *countRequest, stream ServerStream) error
+}
+
+type countServer struct{}
+
+func (countServer) Count(ctx context.Context, req *countRequest, stream ServerStream) error {
+	for i := 0; i < req.N; i++ {
+		if err := stream.SendMsg(&countReply{I: i}); err != nil {
+			return err
+		}
+	}
+	if req.N > 2 {
+		return errors.New(400, 400, "TooMany", "too many")
+	}
+
+	return nil
+}
+
+var countServiceDesc = &ServiceDesc{
+	ServiceName: "test.Counter",
+	HandlerType: (*countService)(nil),
+	Streams: []StreamDesc{
+		{
+			Name:   "Count",
+			Method: "GET",
+			Path:   "/count",
+			Handler: func(srv any, ctx context.Context, dec func(any) error, stream ServerStream, middleware Middleware) error {
+				in := new(countRequest)
+				if err := dec(in); err != nil {
+					return err
+				}
+				_, err := middleware(ctx, in, func(ctx context.Context, req any) (any, error) {
+					return nil, srv.(countService).Count(ctx, in, stream)
+				})
+				return err
+			},
+		},
+	},
+}
+
+func TestStream(t *testing.T) {
+	s := New()
+	s.RegisterService(countServiceDesc, countServer{})
+	ts := httptest.NewServer(s.HttpServer().Handler)
+	defer ts.Close()
+	c, err := NewClient(WithEndpoint(ts.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recvAll := func(n string) ([]int, error) {
+		stream, err := c.NewStream(context.Background(), "GET", "/count?n="+n, nil)
+		if err != nil {
+			return nil, err
+		}
+		defer stream.Close()
+
+		var got []int
+		for {
+			reply := &countReply{}
+			if err := stream.RecvMsg(reply); err != nil {
+				if err == io.EOF {
+					err = nil
+				}
+				return got, err
+			}
+			got = append(got, reply.I)
+		}
+	}
+
+	got, err := recvAll("2")
+	if err != nil || len(got) != 2 || got[1] != 1 {
+		t.Errorf("recv = %v, %v, want [0 1]", got, err)
+	}
+
+	got, err = recvAll("3")
+	if e, ok := err.(errors.Error); !ok || e.Reason() != "TooMany" || len(got) != 3 {
+		t.Errorf("recv = %v, %v, want 3 replies and TooMany error", got, err)
+	}
+
+	if _, err = recvAll("x"); !errors.IsError(err) {
+		t.Errorf("recv error = %v, want bad request", err)
+	}
+}
+
+func TestClientStreamEventStream(t *testing.T) {
+	body := ": comment\n\ndata: {\"data\":{\"i\":1}}\n\nevent: error\ndata: {\"error\":{\"code\":400,\"reason\":\"TooMany\"}}\n\n"
+	stream := &clientStream{
+		body:        io.NopCloser(strings.NewReader(body)),
+		reader:      bufio.NewReader(strings.NewReader(body)),
+		eventStream: true,
+		codec:       encoding.GetCodec(json.Name),
+	}
+
+	reply := &countReply{}
+	if err := stream.RecvMsg(reply); err != nil || reply.I != 1 {
+		t.Errorf("RecvMsg() = %v, %v", reply, err)
+	}
+	if err := stream.RecvMsg(reply); !errors.IsError(err) {
+		t.Errorf("RecvMsg() error = %v, want TooMany", err)
+	}
+	if err := stream.RecvMsg(reply); err != io.EOF {
+		t.Errorf("RecvMsg() error = %v, want io.EOF", err)
+	}
+}
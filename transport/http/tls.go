@@ -0,0 +1,182 @@
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+)
+
+// 证书文件的检查间隔, 在TLS握手时检查文件是否被修改
+const certCheckInterval = 10 * time.Second
+
+// certReloader 加载证书文件, 并在文件修改后重新加载, 用于证书轮换时不需要重启服务
+type certReloader struct {
+	certFile string
+	keyFile  string
+
+	mu        sync.Mutex
+	cert      *tls.Certificate
+	modTime   time.Time
+	lastCheck time.Time
+}
+
+func newCertReloader(certFile, keyFile string) (*certReloader, error) {
+	r := &certReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+	}
+	modTime, err := r.latestModTime()
+	if err != nil {
+		return nil, err
+	}
+	if err := r.load(modTime); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (r *certReloader) latestModTime() (time.Time, error) {
+	var latest time.Time
+	for _, file := range [...]string{r.certFile, r.keyFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("load certificate failed: %w", err)
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+
+	return latest, nil
+}
+
+func (r *certReloader) load(modTime time.Time) error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("load certificate failed: %w", err)
+	}
+	r.cert = &cert
+	r.modTime = modTime
+	r.lastCheck = time.Now()
+
+	return nil
+}
+
+// certificate 返回当前的证书, 文件修改后重新加载, 加载失败时继续使用之前的证书
+func (r *certReloader) certificate() *tls.Certificate {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if time.Since(r.lastCheck) < certCheckInterval {
+		return r.cert
+	}
+	r.lastCheck = time.Now()
+
+	modTime, err := r.latestModTime()
+	if err != nil || !modTime.After(r.modTime) {
+		return r.cert
+	}
+	_ = r.load(modTime)
+
+	return r.cert
+}
+
+func (r *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return r.certificate(), nil
+}
+
+func (r *certReloader) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	return r.certificate(), nil
+}
+
+// loadCertPool 加载PEM格式的CA证书
+func loadCertPool(caFile string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("load ca failed: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, errors.New("load ca failed: no certificate found in " + caFile)
+	}
+
+	return pool, nil
+}
+
+// buildTLSConfig 根据TLS相关的选项生成服务端的tls.Config, 没有配置TLS时返回nil
+func (s *Server) buildTLSConfig() (*tls.Config, error) {
+	if s.tlsConfig == nil && s.certFile == "" && s.clientCAFile == "" {
+		return nil, nil
+	}
+
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if s.tlsConfig != nil {
+		cfg = s.tlsConfig.Clone()
+	}
+
+	if s.certFile != "" {
+		reloader, err := newCertReloader(s.certFile, s.keyFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.GetCertificate = reloader.GetCertificate
+	}
+
+	if s.clientCAFile != "" {
+		pool, err := loadCertPool(s.clientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.ClientCAs = pool
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	if len(cfg.Certificates) == 0 && cfg.GetCertificate == nil && cfg.GetConfigForClient == nil {
+		return nil, errors.New("tls enabled but no certificate provided, use WithCertFiles or set Certificates in WithTLSConfig")
+	}
+
+	return cfg, nil
+}
+
+// Peer 对端的身份信息, 来自mTLS中验证通过的客户端证书
+type Peer struct {
+	Certificate *x509.Certificate
+	CommonName  string
+	DNSNames    []string
+	// SPIFFE ID等URI SAN
+	URIs []*url.URL
+}
+
+// Peer 返回验证通过的客户端身份, 没有开启mTLS或者客户端没有提供证书时返回false
+func (c *Context) Peer() (Peer, bool) {
+	state := c.req.TLS
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return Peer{}, false
+	}
+
+	cert := state.VerifiedChains[0][0]
+	return Peer{
+		Certificate: cert,
+		CommonName:  cert.Subject.CommonName,
+		DNSNames:    cert.DNSNames,
+		URIs:        cert.URIs,
+	}, true
+}
+
+// PeerFromContext 从context中获取验证通过的客户端身份, 用于鉴权中间件
+func PeerFromContext(ctx context.Context) (Peer, bool) {
+	c := FromContext(ctx)
+	if c == nil {
+		return Peer{}, false
+	}
+
+	return c.Peer()
+}
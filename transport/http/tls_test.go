@@ -0,0 +1,158 @@
+package http
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+// writeCert 生成由parent签发的证书并写入dir, parent为nil时生成自签名的CA
+func writeCert(t *testing.T, dir, name string, parent *testCert, tmpl *x509.Certificate) *testCert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl.SerialNumber = big.NewInt(time.Now().UnixNano())
+	tmpl.Subject = pkix.Name{CommonName: name}
+	tmpl.NotBefore = time.Now().Add(-time.Hour)
+	tmpl.NotAfter = time.Now().Add(time.Hour)
+	signer, signerKey := tmpl, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+	if err := os.WriteFile(filepath.Join(dir, name+".crt"), certPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, name+".key"), keyPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+
+	return &testCert{cert: cert, key: key}
+}
+
+func TestMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	path := func(name string) string {
+		return filepath.Join(dir, name)
+	}
+	ca := writeCert(t, dir, "ca", nil, &x509.Certificate{IsCA: true, BasicConstraintsValid: true, KeyUsage: x509.KeyUsageCertSign})
+	writeCert(t, dir, "server", ca, &x509.Certificate{DNSNames: []string{"localhost"}, IPAddresses: localIPs(),
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}})
+	writeCert(t, dir, "client", ca, &x509.Certificate{ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}})
+
+	s := New(WithCertFiles(path("server.crt"), path("server.key")), WithClientCAFile(path("ca.crt")))
+	tlsConfig, err := s.buildTLSConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.server.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		peer, ok := (&Context{req: r}).Peer()
+		if !ok {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = io.WriteString(w, peer.CommonName)
+	})
+	s.server.ErrorLog = log.New(io.Discard, "", 0)
+	go s.server.Serve(tls.NewListener(ln, tlsConfig))
+	defer s.server.Close()
+	url := "https://" + ln.Addr().String()
+
+	t.Run("with client certificate", func(t *testing.T) {
+		c, err := NewClient(WithEndpoint(url), WithRootCAFile(path("ca.crt")), WithClientCertFiles(path("client.crt"), path("client.key")))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := c.client.Get(url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		if string(body) != "client" {
+			t.Errorf("peer = %q, want client", body)
+		}
+	})
+
+	t.Run("without client certificate", func(t *testing.T) {
+		c, err := NewClient(WithEndpoint(url), WithRootCAFile(path("ca.crt")))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp, err := c.client.Get(url); err == nil {
+			resp.Body.Close()
+			t.Error("request without client certificate should fail")
+		}
+	})
+}
+
+func TestCertReload(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key")
+	first := writeCert(t, dir, "server", nil, &x509.Certificate{})
+
+	r, err := newCertReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second := writeCert(t, dir, "server", nil, &x509.Certificate{})
+	future := time.Now().Add(time.Minute)
+	_ = os.Chtimes(certFile, future, future)
+
+	leaf := func() *x509.Certificate {
+		cert, err := x509.ParseCertificate(r.certificate().Certificate[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cert
+	}
+	if !leaf().Equal(first.cert) {
+		t.Fatal("certificate reloaded before check interval")
+	}
+	r.lastCheck = time.Time{}
+	if !leaf().Equal(second.cert) {
+		t.Error("certificate not reloaded after file changed")
+	}
+}
+
+func localIPs() []net.IP {
+	return []net.IP{net.ParseIP("127.0.0.1"), net.ParseIP("::1")}
+}
@@ -0,0 +1,48 @@
+package validator
+
+import (
+	"context"
+
+	"github.com/mangohow/gowlb/errors"
+	"github.com/mangohow/gowlb/transport/http"
+	"github.com/mangohow/gowlb/validate"
+)
+
+const (
+	ReasonInvalidArgument = "InvalidArgument"
+)
+
+type validator interface {
+	Validate() error
+}
+
+// Validator 调用请求参数的Validate方法(由protoc-gen-go-validate生成),
+// 校验失败时返回 errors.BadRequest, 每个字段的失败原因以 字段名 -> 原因 的形式保存在Metadata中
+func Validator() http.Middleware {
+	return func(ctx context.Context, req any, handler http.Handler) (any, error) {
+		v, ok := req.(validator)
+		if !ok {
+			return handler(ctx, req)
+		}
+
+		err := v.Validate()
+		if err == nil {
+			return handler(ctx, req)
+		}
+
+		e := errors.BadRequestCause(errors.UnknownCode, ReasonInvalidArgument, err.Error(), err)
+		if ve, ok := err.(*validate.ValidationError); ok {
+			md := make(map[string]string, len(ve.Violations))
+			for _, violation := range ve.Violations {
+				if desc, ok := md[violation.Field]; ok {
+					md[violation.Field] = desc + "; " + violation.Description
+				} else {
+					md[violation.Field] = violation.Description
+				}
+			}
+			e = errors.WithMetadata(e, md)
+		}
+
+		return nil, e
+	}
+}
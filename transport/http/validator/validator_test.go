@@ -0,0 +1,67 @@
+package validator
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	gerrors "github.com/mangohow/gowlb/errors"
+	"github.com/mangohow/gowlb/validate"
+)
+
+type request struct {
+	err error
+}
+
+func (r *request) Validate() error {
+	return r.err
+}
+
+func TestValidator(t *testing.T) {
+	invalid := &validate.ValidationError{}
+	invalid.Add("name", "value length must be at least 1 runes")
+	invalid.Add("tags[0]", "value is required")
+	invalid.Add("name", "value must match pattern")
+
+	tests := []struct {
+		name     string
+		req      any
+		handled  bool
+		metadata map[string]string
+	}{
+		{"not a validator", "req", true, nil},
+		{"valid", &request{}, true, nil},
+		{"validation error", &request{err: invalid}, false, map[string]string{
+			"name":    "value length must be at least 1 runes; value must match pattern",
+			"tags[0]": "value is required",
+		}},
+		{"other error", &request{err: errors.New("invalid")}, false, nil},
+	}
+
+	for _, tt := range tests {
+		handled := false
+		_, err := Validator()(context.Background(), tt.req, func(ctx context.Context, req any) (any, error) {
+			handled = true
+			return nil, nil
+		})
+		if handled != tt.handled {
+			t.Errorf("%s: handled = %v, want %v", tt.name, handled, tt.handled)
+		}
+		if tt.handled {
+			if err != nil {
+				t.Errorf("%s: error = %v", tt.name, err)
+			}
+			continue
+		}
+
+		e, ok := gerrors.AsError(err)
+		if !ok || e.Code() != gerrors.UnknownCode || e.HttpStatus() != 400 || e.Reason() != ReasonInvalidArgument {
+			t.Errorf("%s: error = %v, want InvalidArgument bad request", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(e.Metadata(), tt.metadata) {
+			t.Errorf("%s: metadata = %v, want %v", tt.name, e.Metadata(), tt.metadata)
+		}
+	}
+}
@@ -0,0 +1,357 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/mangohow/gowlb/encoding"
+	"github.com/mangohow/gowlb/encoding/json"
+	"github.com/mangohow/gowlb/errors"
+)
+
+const (
+	defaultPingInterval = 30 * time.Second
+	wsWriteWait         = 10 * time.Second
+
+	// 错误对应的关闭码为 4000 + HTTP状态码, 关闭帧的reason为 "业务码:错误原因"
+	closeCodeErrorBase = 4000
+	// 关闭帧中reason的最大长度
+	maxCloseReasonLen = 123
+)
+
+// wsStream 通过WebSocket实现的双向流, 在第一次收发消息时才升级连接,
+// 因此在升级之前中间件返回的错误仍然以普通的http响应返回
+// 消息使用子协议对应的编解码器序列化, 子协议为空时使用json
+type wsStream struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	c      *Context
+
+	once       sync.Once
+	upgradeErr error
+	conn       *websocket.Conn
+	codec      encoding.Codec
+	msgType    int
+	pongWait   time.Duration
+
+	// 保证同一时刻只有一个goroutine写入
+	mu   sync.Mutex
+	done chan struct{}
+}
+
+func newWebSocketStream(ctx context.Context, cancel context.CancelFunc, c *Context) *wsStream {
+	return &wsStream{
+		ctx:    ctx,
+		cancel: cancel,
+		c:      c,
+		done:   make(chan struct{}),
+	}
+}
+
+func (s *wsStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *wsStream) upgrade() error {
+	s.once.Do(func() {
+		upgrader := websocket.Upgrader{}
+		if s.c.s.wsUpgrader != nil {
+			upgrader = *s.c.s.wsUpgrader
+		}
+		if len(upgrader.Subprotocols) == 0 {
+			upgrader.Subprotocols = encoding.Names()
+		}
+
+		// 升级失败时Upgrader已经返回了错误响应
+		conn, err := upgrader.Upgrade(s.c.w, s.c.req, nil)
+		if err != nil {
+			s.upgradeErr = err
+			return
+		}
+		s.conn = conn
+
+		name := conn.Subprotocol()
+		if name == "" {
+			name = json.Name
+		}
+		s.codec = encoding.GetCodec(name)
+		s.msgType = websocket.BinaryMessage
+		if name == json.Name {
+			s.msgType = websocket.TextMessage
+		}
+
+		// 收到客户端的关闭帧时不立即回复, 服务端仍然可以继续发送消息
+		conn.SetCloseHandler(func(code int, text string) error {
+			return nil
+		})
+		s.keepalive(s.c.s.wsPingInterval)
+	})
+
+	return s.upgradeErr
+}
+
+// keepalive 定期发送ping, 在pongWait内没有收到pong或者消息时读取失败
+func (s *wsStream) keepalive(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	s.pongWait = interval * 2
+	_ = s.conn.SetReadDeadline(time.Now().Add(s.pongWait))
+	s.conn.SetPongHandler(func(string) error {
+		return s.conn.SetReadDeadline(time.Now().Add(s.pongWait))
+	})
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := s.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteWait)); err != nil {
+					s.cancel()
+					return
+				}
+			case <-s.done:
+				return
+			}
+		}
+	}()
+}
+
+func (s *wsStream) SendMsg(m any) error {
+	if err := s.upgrade(); err != nil {
+		return err
+	}
+
+	data, err := s.codec.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_ = s.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+	if err = s.conn.WriteMessage(s.msgType, data); err != nil {
+		s.cancel()
+	}
+
+	return err
+}
+
+func (s *wsStream) RecvMsg(m any) error {
+	if err := s.upgrade(); err != nil {
+		return err
+	}
+
+	_, data, err := s.conn.ReadMessage()
+	if err != nil {
+		if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+			return io.EOF
+		}
+		s.cancel()
+		return err
+	}
+	if s.pongWait > 0 {
+		_ = s.conn.SetReadDeadline(time.Now().Add(s.pongWait))
+	}
+
+	return s.codec.Unmarshal(data, m)
+}
+
+// finish 根据handler返回的错误发送关闭帧并关闭连接
+func (s *wsStream) finish(err error) error {
+	if s.conn == nil && s.upgradeErr == nil {
+		if err != nil {
+			return err
+		}
+		// 没有收发过消息, 升级之后直接关闭
+		if s.upgrade() != nil {
+			return nil
+		}
+	}
+	if s.upgradeErr != nil {
+		return nil
+	}
+	close(s.done)
+
+	code, text := websocket.CloseNormalClosure, ""
+	if err != nil && s.ctx.Err() == nil {
+		code, text = closeError(err)
+	}
+	s.mu.Lock()
+	_ = s.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, text), time.Now().Add(wsWriteWait))
+	s.mu.Unlock()
+
+	return s.conn.Close()
+}
+
+// closeError 将错误映射为WebSocket关闭码和原因, 业务码编码在原因中以便客户端还原错误
+func closeError(err error) (int, string) {
+	e := toError(err)
+	text := strconv.Itoa(int(e.Code())) + ":" + e.Reason()
+	if len(text) > maxCloseReasonLen {
+		text = text[:maxCloseReasonLen]
+	}
+
+	return closeCodeErrorBase + int(e.HttpStatus()), text
+}
+
+// errorFromClose 将服务端发送的关闭帧转换为错误, 正常关闭时返回io.EOF
+func errorFromClose(err error) error {
+	ce, ok := err.(*websocket.CloseError)
+	if !ok {
+		return err
+	}
+
+	switch {
+	case ce.Code == websocket.CloseNormalClosure || ce.Code == websocket.CloseGoingAway:
+		return io.EOF
+	case ce.Code >= closeCodeErrorBase && ce.Code < closeCodeErrorBase+1000:
+		status := int32(ce.Code - closeCodeErrorBase)
+		code, reason := int32(errors.UnknownCode), ce.Text
+		if before, after, ok := strings.Cut(ce.Text, ":"); ok {
+			if c, err := strconv.ParseInt(before, 10, 32); err == nil {
+				code, reason = int32(c), after
+			}
+		}
+		return errors.New(code, status, reason, reason)
+	}
+
+	return err
+}
+
+// NewWebSocket 调用双向流式rpc方法, 消息使用Content-Type对应的编解码器序列化, 握手请求经过客户端拦截器
+func (c *Client) NewWebSocket(ctx context.Context, path string, opts ...CallOption) (ClientStream, error) {
+	bco := &BeforeCallInfo{
+		Header: make(http.Header),
+	}
+	for _, opt := range opts {
+		opt.Before(bco)
+	}
+
+	contentType := bco.ContentType
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	codec := encoding.GetCodecByContentType(contentType)
+	if codec == nil {
+		return nil, errors.New(errors.UnknownCode, errors.DefaultStatus, errors.UnknownReason, "unsupported Content-Type: "+contentType)
+	}
+
+	dialer := &websocket.Dialer{
+		Proxy:            http.ProxyFromEnvironment,
+		HandshakeTimeout: 45 * time.Second,
+		Subprotocols:     []string{codec.Name()},
+	}
+	if transport, ok := c.client.Transport.(*http.Transport); ok {
+		dialer.Proxy = transport.Proxy
+		dialer.TLSClientConfig = transport.TLSClientConfig
+		dialer.NetDialContext = transport.DialContext
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, c.config.host+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range bco.Header {
+		request.Header.Set(k, v[0])
+	}
+
+	endpoint, done, err := c.pick(request.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	// 握手请求经过客户端拦截器, 拦截器可以通过CallInfo.Request修改握手的请求头
+	var conn *websocket.Conn
+	info := &CallInfo{
+		Operation: bco.Operation,
+		Endpoint:  endpoint,
+		Request:   request,
+		Attempt:   1,
+	}
+	err = c.intercept(ctx, info, nil, func(context.Context, any) (any, error) {
+		u := *info.Request.URL
+		if strings.HasPrefix(u.Scheme, "http") {
+			u.Scheme = "ws" + strings.TrimPrefix(u.Scheme, "http")
+		}
+
+		dialed, response, err := dialer.DialContext(ctx, u.String(), info.Request.Header)
+		info.Response = response
+		if err != nil {
+			if response != nil && err == websocket.ErrBadHandshake {
+				return nil, responseError(response)
+			}
+			return nil, err
+		}
+		conn = dialed
+
+		return nil, nil
+	})
+	done(endpointError(info.Response, err))
+	if err != nil {
+		if conn != nil {
+			conn.Close()
+		}
+		return nil, err
+	}
+
+	for _, opt := range opts {
+		opt.After(&AfterCallInfo{Status: info.Response.StatusCode})
+	}
+
+	msgType := websocket.BinaryMessage
+	if codec.Name() == json.Name {
+		msgType = websocket.TextMessage
+	}
+
+	return &wsClientStream{
+		conn:    conn,
+		codec:   codec,
+		msgType: msgType,
+	}, nil
+}
+
+type wsClientStream struct {
+	conn    *websocket.Conn
+	codec   encoding.Codec
+	msgType int
+
+	mu sync.Mutex
+}
+
+func (s *wsClientStream) SendMsg(m any) error {
+	data, err := s.codec.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.conn.WriteMessage(s.msgType, data)
+}
+
+func (s *wsClientStream) RecvMsg(m any) error {
+	_, data, err := s.conn.ReadMessage()
+	if err != nil {
+		return errorFromClose(err)
+	}
+
+	return s.codec.Unmarshal(data, m)
+}
+
+func (s *wsClientStream) CloseSend() error {
+	return s.conn.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(wsWriteWait))
+}
+
+func (s *wsClientStream) Close() error {
+	return s.conn.Close()
+}
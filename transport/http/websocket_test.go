@@ -0,0 +1,141 @@
+package http
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/mangohow/gowlb/errors"
+)
+
+// errNegative 业务码与http状态码不同, 用于检查客户端能否还原错误
+var errNegative = errors.New(10001, 403, "NEGATIVE", "negative")
+
+type echoService interface {
+	Echo(ctx context.Context, stream ServerStream) error
+}
+
+type echoServer struct{}
+
+func (echoServer) Echo(ctx context.Context, stream ServerStream) error {
+	for {
+		req := &countRequest{}
+		if err := stream.RecvMsg(req); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if req.N < 0 {
+			return errNegative
+		}
+		if err := stream.SendMsg(&countReply{I: req.N}); err != nil {
+			return err
+		}
+	}
+}
+
+var echoServiceDesc = &ServiceDesc{
+	ServiceName: "test.Echo",
+	HandlerType: (*echoService)(nil),
+	Streams: []StreamDesc{
+		{
+			Name:   "Echo",
+			Method: "GET",
+			Path:   "/echo",
+			Handler: func(srv any, ctx context.Context, dec func(any) error, stream ServerStream, middleware Middleware) error {
+				_, err := middleware(ctx, nil, func(ctx context.Context, req any) (any, error) {
+					return nil, srv.(echoService).Echo(ctx, stream)
+				})
+				return err
+			},
+			ClientStreams: true,
+		},
+	},
+}
+
+func TestWebSocket(t *testing.T) {
+	s := New(WithWebSocketPingInterval(10 * time.Millisecond))
+	s.RegisterService(echoServiceDesc, echoServer{})
+	ts := httptest.NewServer(s.HttpServer().Handler)
+	defer ts.Close()
+	c, err := NewClient(WithEndpoint(ts.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stream, err := c.NewWebSocket(context.Background(), "/echo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+
+	recv := func() (*countReply, error) {
+		reply := &countReply{}
+		return reply, stream.RecvMsg(reply)
+	}
+
+	if err := stream.SendMsg(&countRequest{N: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if reply, err := recv(); err != nil || reply.I != 1 {
+		t.Errorf("RecvMsg() = %v, %v, want 1", reply, err)
+	}
+
+	// 客户端在读取时会回复ping, 空闲超过ping间隔后连接仍然可用
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if reply, err := recv(); err != nil || reply.I != 2 {
+			t.Errorf("RecvMsg() = %v, %v, want 2", reply, err)
+		}
+	}()
+	time.Sleep(50 * time.Millisecond)
+	if err := stream.SendMsg(&countRequest{N: 2}); err != nil {
+		t.Fatal(err)
+	}
+	<-done
+
+	if err := stream.SendMsg(&countRequest{N: -1}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := recv(); !errors.Is(err, errNegative) || err.(errors.Error).HttpStatus() != 403 {
+		t.Errorf("RecvMsg() error = %v, want %v", err, errNegative)
+	}
+}
+
+func TestCloseError(t *testing.T) {
+	long := strings.Repeat("R", 200)
+	tests := []struct {
+		err    error
+		code   int32
+		status int32
+		reason string
+	}{
+		{errNegative, 10001, 403, "NEGATIVE"},
+		{errors.NotFound(errors.UnknownCode, "NOT_FOUND", "not found"), errors.UnknownCode, 404, "NOT_FOUND"},
+		{io.ErrUnexpectedEOF, errors.UnknownCode, errors.DefaultStatus, errors.UnknownReason},
+		// 超出长度的reason被截断
+		{errors.New(1, 400, long, ""), 1, 400, long[:maxCloseReasonLen-2]},
+	}
+	for _, tt := range tests {
+		code, text := closeError(tt.err)
+		if len(text) > maxCloseReasonLen {
+			t.Errorf("closeError(%v) reason length = %d", tt.err, len(text))
+		}
+		e, ok := errorFromClose(&websocket.CloseError{Code: code, Text: text}).(errors.Error)
+		if !ok || e.Code() != tt.code || e.HttpStatus() != tt.status || e.Reason() != tt.reason {
+			t.Errorf("errorFromClose(closeError(%v)) = %v, want code %d, status %d, reason %s", tt.err, e, tt.code, tt.status, tt.reason)
+		}
+	}
+
+	// 非本框架服务端发送的关闭帧
+	e, ok := errorFromClose(&websocket.CloseError{Code: closeCodeErrorBase + 500, Text: "oops"}).(errors.Error)
+	if !ok || e.Code() != errors.UnknownCode || e.HttpStatus() != 500 || e.Reason() != "oops" {
+		t.Errorf("errorFromClose() = %v, want unknown code, status 500, reason oops", e)
+	}
+}
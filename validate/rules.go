@@ -0,0 +1,82 @@
+package validate
+
+import (
+	"net"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+var (
+	patterns sync.Map
+
+	uuidPattern     = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	hostnamePattern = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?$`)
+)
+
+// MatchString 使用正则表达式匹配s, 编译后的正则表达式会被缓存
+func MatchString(pattern, s string) bool {
+	if re, ok := patterns.Load(pattern); ok {
+		return re.(*regexp.Regexp).MatchString(s)
+	}
+
+	re := regexp.MustCompile(pattern)
+	patterns.Store(pattern, re)
+
+	return re.MatchString(s)
+}
+
+func IsEmail(s string) bool {
+	addr, err := mail.ParseAddress(s)
+	if err != nil || addr.Address != s {
+		return false
+	}
+
+	_, host, _ := strings.Cut(s, "@")
+	return IsHostname(host)
+}
+
+func IsHostname(s string) bool {
+	s = strings.TrimSuffix(s, ".")
+	if s == "" || len(s) > 253 {
+		return false
+	}
+
+	for _, label := range strings.Split(s, ".") {
+		if !hostnamePattern.MatchString(label) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func IsIP(s string) bool {
+	return net.ParseIP(s) != nil
+}
+
+func IsIPv4(s string) bool {
+	ip := net.ParseIP(s)
+	return ip != nil && ip.To4() != nil && !strings.Contains(s, ":")
+}
+
+func IsIPv6(s string) bool {
+	ip := net.ParseIP(s)
+	return ip != nil && strings.Contains(s, ":")
+}
+
+func IsURI(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && u.IsAbs()
+}
+
+func IsURIRef(s string) bool {
+	_, err := url.Parse(s)
+	return err == nil
+}
+
+func IsUUID(s string) bool {
+	return uuidPattern.MatchString(s)
+}
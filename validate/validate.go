@@ -0,0 +1,85 @@
+package validate
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// FieldViolation 单个字段的校验失败信息
+type FieldViolation struct {
+	Field       string
+	Description string
+}
+
+// ValidationError 校验失败的字段集合, 由protoc-gen-go-validate生成的Validate方法返回
+type ValidationError struct {
+	Violations []FieldViolation
+}
+
+func (e *ValidationError) Error() string {
+	builder := &strings.Builder{}
+	builder.WriteString("validation failed: ")
+	for i, v := range e.Violations {
+		if i > 0 {
+			builder.WriteString("; ")
+		}
+		builder.WriteString("invalid ")
+		builder.WriteString(v.Field)
+		builder.WriteString(": ")
+		builder.WriteString(v.Description)
+	}
+
+	return builder.String()
+}
+
+// Add 添加字段校验失败信息
+func (e *ValidationError) Add(field, description string) {
+	e.Violations = append(e.Violations, FieldViolation{Field: field, Description: description})
+}
+
+// Addf 添加字段校验失败信息
+func (e *ValidationError) Addf(field, format string, args ...interface{}) {
+	e.Add(field, fmt.Sprintf(format, args...))
+}
+
+// Nested 如果v实现了Validate方法, 则调用并合并其校验失败信息, 字段名以field作为前缀
+func (e *ValidationError) Nested(field string, v interface{}) {
+	validator, ok := v.(interface{ Validate() error })
+	if !ok {
+		return
+	}
+
+	err := validator.Validate()
+	if err == nil {
+		return
+	}
+
+	ve, ok := err.(*ValidationError)
+	if !ok {
+		e.Add(field, err.Error())
+		return
+	}
+	for _, violation := range ve.Violations {
+		e.Add(field+"."+violation.Field, violation.Description)
+	}
+}
+
+// Err 没有校验失败的字段时返回nil
+func (e *ValidationError) Err() error {
+	if len(e.Violations) == 0 {
+		return nil
+	}
+
+	return e
+}
+
+// Index 返回repeated字段中第i个元素的字段名, 如 tags[1]
+func Index(field string, i int) string {
+	return field + "[" + strconv.Itoa(i) + "]"
+}
+
+// Key 返回map字段中key对应的字段名, 如 labels[env]
+func Key(field string, key interface{}) string {
+	return fmt.Sprintf("%s[%v]", field, key)
+}
@@ -0,0 +1,69 @@
+package validate
+
+import (
+	"testing"
+)
+
+type nested struct {
+	name string
+}
+
+func (n *nested) Validate() error {
+	if n == nil {
+		return nil
+	}
+
+	var errs ValidationError
+	if n.name == "" {
+		errs.Add("name", "value is required")
+	}
+	return errs.Err()
+}
+
+func TestValidationError(t *testing.T) {
+	var errs ValidationError
+	if errs.Err() != nil {
+		t.Fatalf("Err() = %v, want nil", errs.Err())
+	}
+
+	errs.Add("id", "value must be greater than 0")
+	errs.Nested(Index("users", 1), &nested{})
+	errs.Nested("user", (*nested)(nil))
+
+	err, ok := errs.Err().(*ValidationError)
+	if !ok || len(err.Violations) != 2 {
+		t.Fatalf("Err() = %v", errs.Err())
+	}
+	if field := err.Violations[1].Field; field != "users[1].name" {
+		t.Errorf("nested field = %s, want users[1].name", field)
+	}
+}
+
+func TestRules(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func(string) bool
+		s    string
+		want bool
+	}{
+		{"email", IsEmail, "tom@example.com", true},
+		{"email with name", IsEmail, "Tom <tom@example.com>", false},
+		{"hostname", IsHostname, "api.example.com", true},
+		{"hostname with underscore", IsHostname, "api_v1.example.com", false},
+		{"ipv4", IsIPv4, "127.0.0.1", true},
+		{"ipv4 of ipv6", IsIPv4, "::1", false},
+		{"ipv6", IsIPv6, "::1", true},
+		{"uri", IsURI, "https://example.com/a?b=c", true},
+		{"relative uri", IsURI, "/a/b", false},
+		{"uri ref", IsURIRef, "/a/b", true},
+		{"uuid", IsUUID, "123e4567-e89b-12d3-a456-426614174000", true},
+		{"uuid without hyphen", IsUUID, "123e4567e89b12d3a456426614174000", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.fn(tt.s); got != tt.want {
+				t.Errorf("%s(%q) = %v, want %v", tt.name, tt.s, got, tt.want)
+			}
+		})
+	}
+}